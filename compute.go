@@ -0,0 +1,82 @@
+package ordereddict
+
+// GetOrSet returns the existing value for key if present. Otherwise it sets
+// key to val and returns val. The loaded result is true if the value was
+// loaded, false if it was set. It mirrors sync.Map.LoadOrStore.
+func (o *OrderedDict[K, V]) GetOrSet(key K, val V) (actual V, loaded bool) {
+	o.lock()
+	defer o.unlock()
+	o.expire()
+	if existing, ok := o.s.data[key]; ok {
+		if o.maxLen > 0 {
+			o.stats.Hits++
+			o.unlinkNode(existing)
+			o.linkToEnd(existing)
+		}
+		return existing.val, true
+	}
+	if o.maxLen > 0 {
+		o.stats.Misses++
+	}
+	o.set(key, val, o.ttl)
+	return val, false
+}
+
+// SetIfAbsent sets key to val only if key doesn't exist, returns true if the
+// value was set.
+func (o *OrderedDict[K, V]) SetIfAbsent(key K, val V) bool {
+	o.lock()
+	defer o.unlock()
+	o.expire()
+	if _, ok := o.s.data[key]; ok {
+		return false
+	}
+	o.set(key, val, o.ttl)
+	return true
+}
+
+// Compute atomically updates the entry for key. fn receives the current
+// value and whether key exists, and returns the new value and whether to
+// keep the entry. If keep is true the value is stored as if by Set;
+// otherwise an existing entry is deleted. Compute returns the resulting
+// value and whether key exists afterwards.
+//
+// fn runs while the dictionary is locked, so it must not call back into the
+// dictionary.
+func (o *OrderedDict[K, V]) Compute(key K, fn func(old V, exists bool) (newVal V, keep bool)) (V, bool) {
+	o.lock()
+	defer o.unlock()
+	o.expire()
+
+	var old V
+	existing, exists := o.s.data[key]
+	if exists {
+		old = existing.val
+	}
+
+	newVal, keep := fn(old, exists)
+	if !keep {
+		if exists {
+			o.removeNode(existing)
+		}
+		var zero V
+		return zero, false
+	}
+	o.set(key, newVal, o.ttl)
+	return newVal, true
+}
+
+// CompareAndSwap sets key to newVal if its current value equals old, returns
+// true if the value was swapped. It mirrors sync.Map.CompareAndSwap and is a
+// function rather than a method because it requires comparable values.
+func CompareAndSwap[K comparable, V comparable](o *OrderedDict[K, V], key K, old, newVal V) bool {
+	o.lock()
+	defer o.unlock()
+	o.expire()
+	existing, ok := o.s.data[key]
+	if !ok || existing.val != old {
+		return false
+	}
+	o.set(key, newVal, o.ttl)
+	return true
+}
@@ -0,0 +1,228 @@
+package ordereddict
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGetOrSet(t *testing.T) {
+	od := New[string, int]()
+
+	actual, loaded := od.GetOrSet("a", 1)
+	if loaded || actual != 1 {
+		t.Errorf("expected a=1 to be set, got %d (loaded=%v)", actual, loaded)
+	}
+
+	actual, loaded = od.GetOrSet("a", 2)
+	if !loaded || actual != 1 {
+		t.Errorf("expected existing a=1 to be loaded, got %d (loaded=%v)", actual, loaded)
+	}
+
+	if val, _ := od.Get("a"); val != 1 {
+		t.Errorf("GetOrSet should not overwrite, got %d", val)
+	}
+}
+
+func TestGetOrSetLRU(t *testing.T) {
+	od := NewLRU[string, int](2, nil)
+	od.Set("a", 1)
+	od.Set("b", 2)
+
+	od.GetOrSet("a", 10) // hit: a becomes most recently used
+	od.GetOrSet("c", 3)  // miss: b is evicted
+
+	if od.Has("b") {
+		t.Error("b should be evicted")
+	}
+	if !od.Has("a") {
+		t.Error("a should survive after being loaded")
+	}
+	stats := od.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Evictions != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestSetIfAbsent(t *testing.T) {
+	od := New[string, int]()
+
+	if !od.SetIfAbsent("a", 1) {
+		t.Error("expected SetIfAbsent to set a new key")
+	}
+	if od.SetIfAbsent("a", 2) {
+		t.Error("expected SetIfAbsent to leave an existing key")
+	}
+	if val, _ := od.Get("a"); val != 1 {
+		t.Errorf("expected a=1, got %d", val)
+	}
+}
+
+func TestSetIfAbsentExpired(t *testing.T) {
+	clock := newFakeClock()
+	od := New[string, int](WithClock(clock.Now))
+	od.SetWithTTL("a", 1, time.Second)
+
+	clock.Advance(time.Second)
+	if !od.SetIfAbsent("a", 2) {
+		t.Error("expected SetIfAbsent to replace an expired key")
+	}
+	if val, _ := od.Get("a"); val != 2 {
+		t.Errorf("expected a=2, got %d", val)
+	}
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+
+	if CompareAndSwap(od, "a", 5, 10) {
+		t.Error("expected swap with wrong old value to fail")
+	}
+	if !CompareAndSwap(od, "a", 1, 10) {
+		t.Error("expected swap with matching old value to succeed")
+	}
+	if CompareAndSwap(od, "missing", 0, 1) {
+		t.Error("expected swap of nonexistent key to fail")
+	}
+	if od.Has("missing") {
+		t.Error("failed swap should not insert the key")
+	}
+
+	if val, _ := od.Get("a"); val != 10 {
+		t.Errorf("expected a=10, got %d", val)
+	}
+
+	keys := od.Keys()
+	expected := []string{"a", "b"}
+	for i, key := range keys {
+		if key != expected[i] {
+			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
+		}
+	}
+}
+
+func TestCompute(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+
+	increment := func(old int, exists bool) (int, bool) {
+		return old + 1, true
+	}
+
+	if val, ok := od.Compute("a", increment); !ok || val != 2 {
+		t.Errorf("expected a=2, got %d", val)
+	}
+	if val, ok := od.Compute("c", increment); !ok || val != 1 {
+		t.Errorf("expected c=1, got %d", val)
+	}
+
+	keys := od.Keys()
+	expected := []string{"a", "b", "c"}
+	if len(keys) != len(expected) {
+		t.Fatalf("expected %d keys, got %d", len(expected), len(keys))
+	}
+	for i, key := range keys {
+		if key != expected[i] {
+			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
+		}
+	}
+}
+
+func TestComputeExists(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+
+	var sawOld int
+	var sawExists bool
+	od.Compute("a", func(old int, exists bool) (int, bool) {
+		sawOld, sawExists = old, exists
+		return old, true
+	})
+	if sawOld != 1 || !sawExists {
+		t.Errorf("expected fn to see 1/true, got %d/%v", sawOld, sawExists)
+	}
+
+	od.Compute("missing", func(old int, exists bool) (int, bool) {
+		sawOld, sawExists = old, exists
+		return 0, false
+	})
+	if sawOld != 0 || sawExists {
+		t.Errorf("expected fn to see 0/false, got %d/%v", sawOld, sawExists)
+	}
+	if od.Has("missing") {
+		t.Error("Compute returning keep=false should not insert")
+	}
+}
+
+func TestComputeDelete(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+
+	val, ok := od.Compute("a", func(old int, exists bool) (int, bool) {
+		return 0, false
+	})
+	if ok || val != 0 {
+		t.Errorf("expected deleted result, got %d/%v", val, ok)
+	}
+	if od.Has("a") {
+		t.Error("Compute returning keep=false should delete the key")
+	}
+	if od.Len() != 1 {
+		t.Errorf("expected len=1, got %d", od.Len())
+	}
+	checkList(t, od)
+}
+
+func TestComputeConcurrentCounter(t *testing.T) {
+	od := New[string, int]()
+	var wg sync.WaitGroup
+
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				od.Compute("counter", func(old int, _ bool) (int, bool) {
+					return old + 1, true
+				})
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if val, _ := od.Get("counter"); val != 5000 {
+		t.Errorf("expected counter=5000, got %d", val)
+	}
+}
+
+func TestGetOrSetConcurrent(t *testing.T) {
+	od := New[int, int]()
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	stored := 0
+
+	for i := range 50 {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			for key := range 100 {
+				if _, loaded := od.GetOrSet(key, id); !loaded {
+					mu.Lock()
+					stored++
+					mu.Unlock()
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	if stored != 100 {
+		t.Errorf("expected each key to be stored exactly once, got %d stores", stored)
+	}
+}
@@ -0,0 +1,1056 @@
+package ordereddict
+
+import (
+	"container/heap"
+	"fmt"
+	"hash/maphash"
+	"iter"
+	"math/bits"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ConcurrentOrderedDict is an ordered dictionary for workloads with many
+// goroutines. Keys are spread over independently locked shards, so Get,
+// Has and updates of existing keys on different shards never contend.
+// Values are stored atomically and read without holding any lock. Only
+// changes to the order, such as inserts, deletes and moves, serialize on a
+// single list lock.
+//
+// It has the methods of OrderedDict except for LRU mode and transactions.
+// CompareAndSwapConcurrent takes the place of CompareAndSwap. Each call is
+// atomic for the keys it affects, but batch operations such as SetMany and
+// Merge apply their entries one at a time, so other goroutines may see a
+// batch partly applied. Operations on the whole order, such as sorting,
+// Replace and Snapshot, lock every shard and so briefly stop all other
+// calls.
+type ConcurrentOrderedDict[K comparable, V any] struct {
+	shards []*concurrentShard[K, V]
+	mask   uint64
+	seed   maphash.Seed
+	len    atomic.Int64
+
+	// listMu guards the order: the links of every node and their removed
+	// flags. It is always taken after a shard lock, never before.
+	listMu sync.Mutex
+	head   *concurrentNode[K, V]
+	tail   *concurrentNode[K, V]
+
+	// Expiry, enabled by WithTTL or SetWithTTL. expMu guards the expiry
+	// heap and the heap positions of the nodes, and is never held while
+	// taking another lock. next holds the earliest expiry time in the heap,
+	// or 0 if it is empty, so that writers can skip expMu until something
+	// is due.
+	ttl    time.Duration
+	now    func() time.Time
+	timed  atomic.Bool
+	expMu  sync.Mutex
+	expiry concurrentExpiry[K, V]
+	next   atomic.Int64
+	stop   chan struct{}
+	closed sync.Once
+}
+
+type concurrentShard[K comparable, V any] struct {
+	mu   sync.RWMutex
+	data map[K]*concurrentNode[K, V]
+
+	// Keep shards on separate cache lines.
+	_ [64]byte
+}
+
+type concurrentNode[K comparable, V any] struct {
+	key     K
+	val     atomic.Pointer[V]
+	prev    *concurrentNode[K, V]
+	next    *concurrentNode[K, V]
+	removed bool
+
+	// expires is the expiry time in Unix nanoseconds, or 0 if the entry
+	// never expires. It is written with the shard lock and expMu held.
+	// hidx is the node's position in the expiry heap, or -1 if it is not
+	// in the heap.
+	expires atomic.Int64
+	hidx    int
+}
+
+func newConcurrentNode[K comparable, V any](key K, val V) *concurrentNode[K, V] {
+	n := &concurrentNode[K, V]{key: key, hidx: -1}
+	n.val.Store(&val)
+	return n
+}
+
+// NewConcurrent creates a new ConcurrentOrderedDict with a shard count
+// suited to GOMAXPROCS. It accepts the same options as New.
+func NewConcurrent[K comparable, V any](opts ...Option) *ConcurrentOrderedDict[K, V] {
+	n := 1 << bits.Len(uint(runtime.GOMAXPROCS(0)*4-1))
+	head := &concurrentNode[K, V]{}
+	tail := &concurrentNode[K, V]{}
+	head.next = tail
+	tail.prev = head
+	c := &ConcurrentOrderedDict[K, V]{
+		shards: make([]*concurrentShard[K, V], n),
+		mask:   uint64(n - 1),
+		seed:   maphash.MakeSeed(),
+		head:   head,
+		tail:   tail,
+	}
+	for i := range c.shards {
+		c.shards[i] = &concurrentShard[K, V]{data: make(map[K]*concurrentNode[K, V])}
+	}
+
+	cfg := applyOptions(opts)
+	c.ttl = cfg.ttl
+	c.now = cfg.now
+	if cfg.janitor > 0 {
+		c.stop = make(chan struct{})
+		go c.janitor(cfg.janitor, c.stop)
+	}
+	return c
+}
+
+func (c *ConcurrentOrderedDict[K, V]) shard(key K) *concurrentShard[K, V] {
+	return c.shards[c.shardIndex(key)]
+}
+
+func (c *ConcurrentOrderedDict[K, V]) shardIndex(key K) uint64 {
+	return maphash.Comparable(c.seed, key) & c.mask
+}
+
+// lookup returns the node for key, or nil if it doesn't exist or has
+// expired.
+func (c *ConcurrentOrderedDict[K, V]) lookup(key K) *concurrentNode[K, V] {
+	s := c.shard(key)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n := s.data[key]
+	if n == nil || c.expired(n) {
+		return nil
+	}
+	return n
+}
+
+// find returns the node for key, or nil. An expired node is removed and
+// reported as missing. It must be called with the shard lock held.
+func (c *ConcurrentOrderedDict[K, V]) find(s *concurrentShard[K, V], key K) *concurrentNode[K, V] {
+	n := s.data[key]
+	if n != nil && c.expired(n) {
+		c.remove(s, n)
+		return nil
+	}
+	return n
+}
+
+// Set adds or updates a key-value pair.
+func (c *ConcurrentOrderedDict[K, V]) Set(key K, val V) {
+	c.set(key, val, c.ttl)
+}
+
+// SetWithTTL adds or updates a key-value pair that expires after ttl. A
+// non-positive ttl means the entry never expires.
+func (c *ConcurrentOrderedDict[K, V]) SetWithTTL(key K, val V, ttl time.Duration) {
+	c.set(key, val, ttl)
+}
+
+func (c *ConcurrentOrderedDict[K, V]) set(key K, val V, ttl time.Duration) {
+	c.expire()
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n := c.find(s, key); n != nil {
+		n.val.Store(&val)
+		c.setExpiry(n, ttl)
+		return
+	}
+	c.add(s, key, val, ttl)
+}
+
+// add links a new node for key at the end. It must be called with the
+// shard lock held.
+func (c *ConcurrentOrderedDict[K, V]) add(s *concurrentShard[K, V], key K, val V, ttl time.Duration) {
+	n := newConcurrentNode(key, val)
+	c.setExpiry(n, ttl)
+	s.data[key] = n
+	c.listMu.Lock()
+	c.linkAfter(n, c.tail.prev)
+	c.listMu.Unlock()
+	c.len.Add(1)
+}
+
+// SetFirst adds or updates a key-value pair and places it at the start of
+// the order. An existing key is moved as well as updated.
+func (c *ConcurrentOrderedDict[K, V]) SetFirst(key K, val V) {
+	c.setAt(key, val, nil, false)
+}
+
+// SetAfter adds or updates a key-value pair and places it directly after
+// another key, returns false if the after key doesn't exist.
+// An existing key is moved as well as updated.
+func (c *ConcurrentOrderedDict[K, V]) SetAfter(after K, key K, val V) bool {
+	return c.setNear(after, key, val, false)
+}
+
+// SetBefore adds or updates a key-value pair and places it directly before
+// another key, returns false if the before key doesn't exist.
+// An existing key is moved as well as updated.
+func (c *ConcurrentOrderedDict[K, V]) SetBefore(before K, key K, val V) bool {
+	return c.setNear(before, key, val, true)
+}
+
+// setNear implements SetAfter and SetBefore, retrying if the anchor is
+// deleted while the key's shard is being locked.
+func (c *ConcurrentOrderedDict[K, V]) setNear(anchor K, key K, val V, before bool) bool {
+	for {
+		a := c.lookup(anchor)
+		if a == nil {
+			return false
+		}
+		if c.setAt(key, val, a, before) {
+			return true
+		}
+	}
+}
+
+// setAt adds or updates key and places it next to the anchor node, or at
+// the start if anchor is nil. It returns false without changing anything
+// if the anchor has been removed.
+func (c *ConcurrentOrderedDict[K, V]) setAt(key K, val V, anchor *concurrentNode[K, V], before bool) bool {
+	c.expire()
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := c.find(s, key)
+	c.listMu.Lock()
+	defer c.listMu.Unlock()
+
+	prev := c.head
+	if anchor != nil {
+		if anchor.removed {
+			return false
+		}
+		prev = anchor
+		if before {
+			prev = anchor.prev
+		}
+	}
+
+	switch {
+	case n == nil:
+		n = newConcurrentNode(key, val)
+		s.data[key] = n
+		c.len.Add(1)
+	case n == prev:
+		n.val.Store(&val)
+		c.setExpiry(n, c.ttl)
+		return true
+	default:
+		c.unlink(n)
+	}
+	n.val.Store(&val)
+	c.setExpiry(n, c.ttl)
+	c.linkAfter(n, prev)
+	return true
+}
+
+// Get retrieves a value by key, returns false if key doesn't exist.
+func (c *ConcurrentOrderedDict[K, V]) Get(key K) (V, bool) {
+	n := c.lookup(key)
+	if n == nil {
+		var zero V
+		return zero, false
+	}
+	return *n.val.Load(), true
+}
+
+// Has checks if a key exists in the dictionary.
+func (c *ConcurrentOrderedDict[K, V]) Has(key K) bool {
+	return c.lookup(key) != nil
+}
+
+// GetOrSet returns the existing value for key if present. Otherwise it sets
+// key to val and returns val. The loaded result is true if the value was
+// loaded, false if it was set.
+func (c *ConcurrentOrderedDict[K, V]) GetOrSet(key K, val V) (actual V, loaded bool) {
+	if n := c.lookup(key); n != nil {
+		return *n.val.Load(), true
+	}
+	c.expire()
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n := c.find(s, key); n != nil {
+		return *n.val.Load(), true
+	}
+	c.add(s, key, val, c.ttl)
+	return val, false
+}
+
+// SetIfAbsent sets key to val only if key doesn't exist, returns true if the
+// value was set.
+func (c *ConcurrentOrderedDict[K, V]) SetIfAbsent(key K, val V) bool {
+	_, loaded := c.GetOrSet(key, val)
+	return !loaded
+}
+
+// Compute atomically updates the entry for key, as OrderedDict.Compute
+// does. fn runs while the key's shard is locked, so it must not call back
+// into the dictionary.
+func (c *ConcurrentOrderedDict[K, V]) Compute(key K, fn func(old V, exists bool) (newVal V, keep bool)) (V, bool) {
+	c.expire()
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var old V
+	n := c.find(s, key)
+	exists := n != nil
+	if exists {
+		old = *n.val.Load()
+	}
+
+	newVal, keep := fn(old, exists)
+	switch {
+	case !keep:
+		if exists {
+			c.remove(s, n)
+		}
+		var zero V
+		return zero, false
+	case exists:
+		n.val.Store(&newVal)
+		c.setExpiry(n, c.ttl)
+	default:
+		c.add(s, key, newVal, c.ttl)
+	}
+	return newVal, true
+}
+
+// CompareAndSwapConcurrent sets key to newVal if its current value equals
+// old, returns true if the value was swapped. It is the counterpart of
+// CompareAndSwap for a ConcurrentOrderedDict.
+func CompareAndSwapConcurrent[K comparable, V comparable](c *ConcurrentOrderedDict[K, V], key K, old, newVal V) bool {
+	c.expire()
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := c.find(s, key)
+	if n == nil || *n.val.Load() != old {
+		return false
+	}
+	n.val.Store(&newVal)
+	c.setExpiry(n, c.ttl)
+	return true
+}
+
+// Delete removes a key and returns its value, returns false if key doesn't exist.
+func (c *ConcurrentOrderedDict[K, V]) Delete(key K) (V, bool) {
+	c.expire()
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := c.find(s, key)
+	if n == nil {
+		var zero V
+		return zero, false
+	}
+	c.remove(s, n)
+	return *n.val.Load(), true
+}
+
+// Remove deletes a key, returns true if key existed.
+func (c *ConcurrentOrderedDict[K, V]) Remove(key K) bool {
+	_, ok := c.Delete(key)
+	return ok
+}
+
+// remove drops n from its shard and the order. It must be called with the
+// shard lock held.
+func (c *ConcurrentOrderedDict[K, V]) remove(s *concurrentShard[K, V], n *concurrentNode[K, V]) {
+	c.listMu.Lock()
+	c.drop(s, n)
+	c.listMu.Unlock()
+}
+
+// drop is like remove, but must be called with the list lock held as well.
+func (c *ConcurrentOrderedDict[K, V]) drop(s *concurrentShard[K, V], n *concurrentNode[K, V]) {
+	delete(s.data, n.key)
+	c.unlink(n)
+	n.removed = true
+	c.len.Add(-1)
+	if n.expires.Load() != 0 {
+		c.expireAt(n, 0)
+	}
+}
+
+// PopFirst removes and returns the first key-value pair, returns false if
+// the dictionary is empty.
+func (c *ConcurrentOrderedDict[K, V]) PopFirst() (K, V, bool) {
+	return c.pop(false)
+}
+
+// PopLast removes and returns the last key-value pair, returns false if the
+// dictionary is empty.
+func (c *ConcurrentOrderedDict[K, V]) PopLast() (K, V, bool) {
+	return c.pop(true)
+}
+
+// PopItem removes and returns the last key-value pair if last is true, or
+// the first one otherwise, returns false if the dictionary is empty.
+func (c *ConcurrentOrderedDict[K, V]) PopItem(last bool) (K, V, bool) {
+	return c.pop(last)
+}
+
+func (c *ConcurrentOrderedDict[K, V]) pop(last bool) (K, V, bool) {
+	c.expire()
+	for {
+		c.listMu.Lock()
+		n := c.end(last)
+		c.listMu.Unlock()
+		if n == c.head || n == c.tail {
+			var zeroK K
+			var zeroV V
+			return zeroK, zeroV, false
+		}
+
+		// The list lock can't be held while taking the shard lock, so
+		// another goroutine may remove n or link a node in front of it in
+		// between. Check that n is still at the end once both locks are
+		// held, and try again if not. An expired node is removed and
+		// skipped.
+		s := c.shard(n.key)
+		s.mu.Lock()
+		c.listMu.Lock()
+		if c.end(last) != n {
+			c.listMu.Unlock()
+			s.mu.Unlock()
+			continue
+		}
+		expired := c.expired(n)
+		c.drop(s, n)
+		c.listMu.Unlock()
+		s.mu.Unlock()
+		if !expired {
+			return n.key, *n.val.Load(), true
+		}
+	}
+}
+
+// end returns the last node if last is set, or the first one otherwise. It
+// returns a sentinel if the list is empty. It must be called with the list
+// lock held.
+func (c *ConcurrentOrderedDict[K, V]) end(last bool) *concurrentNode[K, V] {
+	if last {
+		return c.tail.prev
+	}
+	return c.head.next
+}
+
+// First returns the first key-value pair without removing it, returns false
+// if the dictionary is empty.
+func (c *ConcurrentOrderedDict[K, V]) First() (K, V, bool) {
+	c.listMu.Lock()
+	defer c.listMu.Unlock()
+	return c.entry(c.skip(c.head.next, false))
+}
+
+// Last returns the last key-value pair without removing it, returns false
+// if the dictionary is empty.
+func (c *ConcurrentOrderedDict[K, V]) Last() (K, V, bool) {
+	c.listMu.Lock()
+	defer c.listMu.Unlock()
+	return c.entry(c.skip(c.tail.prev, true))
+}
+
+// Next returns the key-value pair that follows key in the order, returns
+// false if key doesn't exist or is the last key.
+func (c *ConcurrentOrderedDict[K, V]) Next(key K) (K, V, bool) {
+	return c.adjacent(key, false)
+}
+
+// Prev returns the key-value pair that precedes key in the order, returns
+// false if key doesn't exist or is the first key.
+func (c *ConcurrentOrderedDict[K, V]) Prev(key K) (K, V, bool) {
+	return c.adjacent(key, true)
+}
+
+// adjacent implements Next and Prev, retrying if the node for key is
+// removed after it is looked up.
+func (c *ConcurrentOrderedDict[K, V]) adjacent(key K, backward bool) (K, V, bool) {
+	for {
+		n := c.lookup(key)
+		if n == nil {
+			var zeroK K
+			var zeroV V
+			return zeroK, zeroV, false
+		}
+		c.listMu.Lock()
+		if !n.removed {
+			next := n.next
+			if backward {
+				next = n.prev
+			}
+			k, v, ok := c.entry(c.skip(next, backward))
+			c.listMu.Unlock()
+			return k, v, ok
+		}
+		c.listMu.Unlock()
+	}
+}
+
+// skip returns n, or the first node after it, or before it if backward is
+// set, that has not expired. It must be called with the list lock held.
+func (c *ConcurrentOrderedDict[K, V]) skip(n *concurrentNode[K, V], backward bool) *concurrentNode[K, V] {
+	for n != c.head && n != c.tail && c.expired(n) {
+		if backward {
+			n = n.prev
+		} else {
+			n = n.next
+		}
+	}
+	return n
+}
+
+func (c *ConcurrentOrderedDict[K, V]) entry(n *concurrentNode[K, V]) (K, V, bool) {
+	if n == c.head || n == c.tail {
+		var zeroK K
+		var zeroV V
+		return zeroK, zeroV, false
+	}
+	return n.key, *n.val.Load(), true
+}
+
+// Len returns the number of items in the dictionary.
+func (c *ConcurrentOrderedDict[K, V]) Len() int {
+	c.expire()
+	return int(c.len.Load())
+}
+
+// MoveToEnd moves a key to the end of the order, returns false if key doesn't exist.
+func (c *ConcurrentOrderedDict[K, V]) MoveToEnd(key K) bool {
+	return c.move(key, func(n *concurrentNode[K, V]) {
+		c.unlink(n)
+		c.linkAfter(n, c.tail.prev)
+	})
+}
+
+// MoveToStart moves a key to the start of the order, returns false if key doesn't exist.
+func (c *ConcurrentOrderedDict[K, V]) MoveToStart(key K) bool {
+	return c.move(key, func(n *concurrentNode[K, V]) {
+		c.unlink(n)
+		c.linkAfter(n, c.head)
+	})
+}
+
+// MoveAfter moves a key after another key, returns false if either key doesn't
+// exist or both are the same key.
+func (c *ConcurrentOrderedDict[K, V]) MoveAfter(key K, after K) bool {
+	return c.moveNear(key, after, false)
+}
+
+// MoveBefore moves a key before another key, returns false if either key
+// doesn't exist or both are the same key.
+func (c *ConcurrentOrderedDict[K, V]) MoveBefore(key K, before K) bool {
+	return c.moveNear(key, before, true)
+}
+
+// move calls relink on the node for key with the list locked. If the node
+// is removed after it is looked up, the lookup is retried.
+func (c *ConcurrentOrderedDict[K, V]) move(key K, relink func(n *concurrentNode[K, V])) bool {
+	for {
+		n := c.lookup(key)
+		if n == nil {
+			return false
+		}
+		c.listMu.Lock()
+		if !n.removed {
+			relink(n)
+			c.listMu.Unlock()
+			return true
+		}
+		c.listMu.Unlock()
+	}
+}
+
+func (c *ConcurrentOrderedDict[K, V]) moveNear(key K, anchor K, before bool) bool {
+	if key == anchor {
+		return false
+	}
+	for {
+		n, a := c.lookup(key), c.lookup(anchor)
+		if n == nil || a == nil {
+			return false
+		}
+		c.listMu.Lock()
+		if !n.removed && !a.removed {
+			c.unlink(n)
+			if before {
+				c.linkAfter(n, a.prev)
+			} else {
+				c.linkAfter(n, a)
+			}
+			c.listMu.Unlock()
+			return true
+		}
+		c.listMu.Unlock()
+	}
+}
+
+// Clear removes all items from the dictionary.
+func (c *ConcurrentOrderedDict[K, V]) Clear() {
+	c.reset(nil)
+}
+
+// Replace replaces the contents of the dictionary with the entries of src,
+// in src's order, as a single step, as OrderedDict.Replace does. Entries
+// take the dictionary's default TTL. src is copied in O(n) with all of its
+// locks held, but never while c is locked, so a.Replace(b) may safely run
+// concurrently with b.Replace(a). A nil src empties the dictionary.
+func (c *ConcurrentOrderedDict[K, V]) Replace(src *ConcurrentOrderedDict[K, V]) {
+	if src == c {
+		return
+	}
+	if src == nil {
+		c.Clear()
+		return
+	}
+	src.lockAll()
+	entries := src.entries()
+	src.unlockAll()
+	c.reset(entries)
+}
+
+// reset replaces the contents of the dictionary with entries, all at once.
+// The entries take the default TTL.
+func (c *ConcurrentOrderedDict[K, V]) reset(entries []concurrentEntry[K, V]) {
+	c.lockAll()
+	defer c.unlockAll()
+	for curr := c.head.next; curr != c.tail; curr = curr.next {
+		curr.removed = true
+	}
+	for _, s := range c.shards {
+		clear(s.data)
+	}
+	c.head.next = c.tail
+	c.tail.prev = c.head
+	c.len.Store(0)
+	c.expMu.Lock()
+	for _, n := range c.expiry {
+		n.hidx = -1
+	}
+	c.expiry = nil
+	c.next.Store(0)
+	c.expMu.Unlock()
+
+	var expires int64
+	if c.ttl > 0 {
+		expires = c.clock() + int64(c.ttl)
+	}
+	for i := range entries {
+		entries[i].expires = expires
+	}
+	c.load(entries)
+}
+
+func (c *ConcurrentOrderedDict[K, V]) linkAfter(n, prev *concurrentNode[K, V]) {
+	n.prev = prev
+	n.next = prev.next
+	prev.next.prev = n
+	prev.next = n
+}
+
+func (c *ConcurrentOrderedDict[K, V]) unlink(n *concurrentNode[K, V]) {
+	n.prev.next = n.next
+	n.next.prev = n.prev
+}
+
+// nodes returns the unexpired nodes in order, or in reverse if backward is
+// set. Iterators walk this copy so that the list lock is not held while the
+// loop body runs.
+func (c *ConcurrentOrderedDict[K, V]) nodes(backward bool) []*concurrentNode[K, V] {
+	c.expire()
+	c.listMu.Lock()
+	defer c.listMu.Unlock()
+	nodes := make([]*concurrentNode[K, V], 0, c.len.Load())
+	if backward {
+		for curr := c.tail.prev; curr != c.head; curr = curr.prev {
+			if !c.expired(curr) {
+				nodes = append(nodes, curr)
+			}
+		}
+		return nodes
+	}
+	for curr := c.head.next; curr != c.tail; curr = curr.next {
+		if !c.expired(curr) {
+			nodes = append(nodes, curr)
+		}
+	}
+	return nodes
+}
+
+// Keys returns all keys in order.
+func (c *ConcurrentOrderedDict[K, V]) Keys() []K {
+	nodes := c.nodes(false)
+	k := make([]K, len(nodes))
+	for i, n := range nodes {
+		k[i] = n.key
+	}
+	return k
+}
+
+// Values returns all values in order.
+func (c *ConcurrentOrderedDict[K, V]) Values() []V {
+	nodes := c.nodes(false)
+	v := make([]V, len(nodes))
+	for i, n := range nodes {
+		v[i] = *n.val.Load()
+	}
+	return v
+}
+
+// All returns an iterator over key-value pairs in order. It visits the
+// entries present when iteration starts, with their values at the time
+// each is visited, and the loop body may modify the dictionary.
+func (c *ConcurrentOrderedDict[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for _, n := range c.nodes(false) {
+			if !yield(n.key, *n.val.Load()) {
+				return
+			}
+		}
+	}
+}
+
+// KeysSeq returns an iterator over keys in order, like All.
+func (c *ConcurrentOrderedDict[K, V]) KeysSeq() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for _, n := range c.nodes(false) {
+			if !yield(n.key) {
+				return
+			}
+		}
+	}
+}
+
+// ValuesSeq returns an iterator over values in order, like All.
+func (c *ConcurrentOrderedDict[K, V]) ValuesSeq() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for _, n := range c.nodes(false) {
+			if !yield(*n.val.Load()) {
+				return
+			}
+		}
+	}
+}
+
+// Backward returns an iterator over key-value pairs in reverse order, like
+// All.
+func (c *ConcurrentOrderedDict[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for _, n := range c.nodes(true) {
+			if !yield(n.key, *n.val.Load()) {
+				return
+			}
+		}
+	}
+}
+
+// KeysBackward returns an iterator over keys in reverse order, like All.
+func (c *ConcurrentOrderedDict[K, V]) KeysBackward() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for _, n := range c.nodes(true) {
+			if !yield(n.key) {
+				return
+			}
+		}
+	}
+}
+
+// ValuesBackward returns an iterator over values in reverse order, like
+// All.
+func (c *ConcurrentOrderedDict[K, V]) ValuesBackward() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for _, n := range c.nodes(true) {
+			if !yield(*n.val.Load()) {
+				return
+			}
+		}
+	}
+}
+
+// SetMany adds or updates every key-value pair produced by seq, in order,
+// as if by Set. Unlike OrderedDict.SetMany, the pairs are not applied
+// atomically as a group.
+func (c *ConcurrentOrderedDict[K, V]) SetMany(seq iter.Seq2[K, V]) {
+	for key, val := range seq {
+		c.Set(key, val)
+	}
+}
+
+// DeleteMany removes the given keys and returns the number of keys that
+// existed. Each key is deleted as if by Delete.
+func (c *ConcurrentOrderedDict[K, V]) DeleteMany(keys ...K) int {
+	deleted := 0
+	for _, key := range keys {
+		if c.Remove(key) {
+			deleted++
+		}
+	}
+	return deleted
+}
+
+// GetMany retrieves the given keys. The value and found flag of keys[i] are
+// at index i of the returned slices; missing keys get the zero value. Each
+// key is looked up as if by Get.
+func (c *ConcurrentOrderedDict[K, V]) GetMany(keys ...K) ([]V, []bool) {
+	vals := make([]V, len(keys))
+	found := make([]bool, len(keys))
+	for i, key := range keys {
+		vals[i], found[i] = c.Get(key)
+	}
+	return vals, found
+}
+
+// Merge merges another ConcurrentOrderedDict into this one, as
+// OrderedDict.Merge does. It reads other's order when the call starts and
+// merges the entries one at a time, so a.Merge(b) may safely run
+// concurrently with b.Merge(a).
+func (c *ConcurrentOrderedDict[K, V]) Merge(other *ConcurrentOrderedDict[K, V]) {
+	c.merge(other, nil, false)
+}
+
+// MergeWith is like Merge, but for keys present in both dictionaries it
+// stores fn(key, old, new) instead of the value from other. fn runs while
+// the key's shard is locked, so it must not call back into the dictionary.
+func (c *ConcurrentOrderedDict[K, V]) MergeWith(other *ConcurrentOrderedDict[K, V], fn func(key K, old, new V) V) {
+	c.merge(other, fn, false)
+}
+
+// MergeToEnd is like Merge, but keys that already exist are moved to the
+// end.
+func (c *ConcurrentOrderedDict[K, V]) MergeToEnd(other *ConcurrentOrderedDict[K, V]) {
+	c.merge(other, nil, true)
+}
+
+func (c *ConcurrentOrderedDict[K, V]) merge(other *ConcurrentOrderedDict[K, V], fn func(key K, old, new V) V, toEnd bool) {
+	if other == nil || other == c {
+		return
+	}
+	for _, on := range other.nodes(false) {
+		c.mergeEntry(on.key, *on.val.Load(), fn, toEnd)
+	}
+}
+
+func (c *ConcurrentOrderedDict[K, V]) mergeEntry(key K, val V, fn func(key K, old, new V) V, toEnd bool) {
+	c.expire()
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := c.find(s, key)
+	if n == nil {
+		c.add(s, key, val, c.ttl)
+		return
+	}
+	if fn != nil {
+		val = fn(key, *n.val.Load(), val)
+	}
+	n.val.Store(&val)
+	c.setExpiry(n, c.ttl)
+	if toEnd {
+		c.listMu.Lock()
+		c.unlink(n)
+		c.linkAfter(n, c.tail.prev)
+		c.listMu.Unlock()
+	}
+}
+
+// MarshalJSON encodes the dictionary as a JSON object whose members appear
+// in order, with keys encoded as OrderedDict.MarshalJSON does.
+func (c *ConcurrentOrderedDict[K, V]) MarshalJSON() ([]byte, error) {
+	if c == nil {
+		return []byte("null"), nil
+	}
+	return marshalObject(c.All())
+}
+
+// UnmarshalJSON decodes a JSON object into the dictionary, replacing its
+// contents all at once, as OrderedDict.UnmarshalJSON does.
+func (c *ConcurrentOrderedDict[K, V]) UnmarshalJSON(data []byte) error {
+	tmp, err := unmarshalObject[K, V](data, reflect.TypeOf(c))
+	if tmp == nil {
+		return err
+	}
+	entries := make([]concurrentEntry[K, V], 0, tmp.s.len)
+	for key, val := range tmp.s.all() {
+		entries = append(entries, concurrentEntry[K, V]{key: key, val: val})
+	}
+	c.reset(entries)
+	return nil
+}
+
+// Close stops the janitor started by WithJanitor. It is safe to call Close
+// more than once and on dictionaries without a janitor.
+func (c *ConcurrentOrderedDict[K, V]) Close() error {
+	c.closed.Do(func() {
+		if c.stop != nil {
+			close(c.stop)
+		}
+	})
+	return nil
+}
+
+// String pretty prints the dictionary.
+func (c *ConcurrentOrderedDict[K, V]) String() string {
+	var sb strings.Builder
+	sb.WriteString("ConcurrentOrderedDict[")
+	for i, n := range c.nodes(false) {
+		if i > 0 {
+			sb.WriteString(" ")
+		}
+		fmt.Fprintf(&sb, "%v:%v", n.key, *n.val.Load())
+	}
+	sb.WriteString("]")
+	return sb.String()
+}
+
+// expired reports whether n has passed its expiry time.
+func (c *ConcurrentOrderedDict[K, V]) expired(n *concurrentNode[K, V]) bool {
+	expires := n.expires.Load()
+	return expires != 0 && expires <= c.clock()
+}
+
+// setExpiry sets a node to expire after ttl, or never if ttl is not
+// positive. It must be called with the node's shard lock held.
+func (c *ConcurrentOrderedDict[K, V]) setExpiry(n *concurrentNode[K, V], ttl time.Duration) {
+	if ttl <= 0 {
+		if n.expires.Load() != 0 {
+			c.expireAt(n, 0)
+		}
+		return
+	}
+	if !c.timed.Load() {
+		c.timed.Store(true)
+	}
+	c.expireAt(n, c.clock()+int64(ttl))
+}
+
+// expireAt sets a node to expire at the given Unix nanosecond time, or
+// never if expires is 0, keeping the expiry heap in sync. It must be called
+// with the node's shard lock held.
+func (c *ConcurrentOrderedDict[K, V]) expireAt(n *concurrentNode[K, V], expires int64) {
+	c.expMu.Lock()
+	defer c.expMu.Unlock()
+	n.expires.Store(expires)
+	switch {
+	case expires == 0:
+		if n.hidx >= 0 {
+			heap.Remove(&c.expiry, n.hidx)
+		}
+	case n.hidx >= 0:
+		heap.Fix(&c.expiry, n.hidx)
+	default:
+		heap.Push(&c.expiry, n)
+	}
+	c.updateNext()
+}
+
+// updateNext publishes the earliest expiry time in the heap. It must be
+// called with expMu held.
+func (c *ConcurrentOrderedDict[K, V]) updateNext() {
+	if len(c.expiry) > 0 {
+		c.next.Store(c.expiry[0].expires.Load())
+	} else {
+		c.next.Store(0)
+	}
+}
+
+// expire removes the entries whose expiry time has passed. It must be
+// called without holding any lock.
+func (c *ConcurrentOrderedDict[K, V]) expire() {
+	if !c.timed.Load() {
+		return
+	}
+	now := c.clock()
+	if next := c.next.Load(); next == 0 || next > now {
+		return
+	}
+
+	// A node may be removed or given a new expiry time before its shard is
+	// locked; if so, leave it alone.
+	for _, n := range c.due(now) {
+		s := c.shard(n.key)
+		s.mu.Lock()
+		if s.data[n.key] == n && c.expired(n) {
+			c.remove(s, n)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// due takes the nodes whose expiry time is at or before now off the expiry
+// heap and returns them. The caller removes those that are still current.
+func (c *ConcurrentOrderedDict[K, V]) due(now int64) []*concurrentNode[K, V] {
+	c.expMu.Lock()
+	defer c.expMu.Unlock()
+	var due []*concurrentNode[K, V]
+	for len(c.expiry) > 0 && c.expiry[0].expires.Load() <= now {
+		due = append(due, heap.Pop(&c.expiry).(*concurrentNode[K, V]))
+	}
+	c.updateNext()
+	return due
+}
+
+// clock returns the current time in Unix nanoseconds.
+func (c *ConcurrentOrderedDict[K, V]) clock() int64 {
+	if c.now != nil {
+		return c.now().UnixNano()
+	}
+	return time.Now().UnixNano()
+}
+
+// janitor periodically reclaims expired entries until stop is closed.
+func (c *ConcurrentOrderedDict[K, V]) janitor(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.expire()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// concurrentExpiry is a min-heap of expiring nodes ordered by expiry time.
+type concurrentExpiry[K comparable, V any] []*concurrentNode[K, V]
+
+func (h concurrentExpiry[K, V]) Len() int { return len(h) }
+
+func (h concurrentExpiry[K, V]) Less(i, j int) bool {
+	return h[i].expires.Load() < h[j].expires.Load()
+}
+
+func (h concurrentExpiry[K, V]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].hidx = i
+	h[j].hidx = j
+}
+
+func (h *concurrentExpiry[K, V]) Push(x any) {
+	n := x.(*concurrentNode[K, V])
+	n.hidx = len(*h)
+	*h = append(*h, n)
+}
+
+func (h *concurrentExpiry[K, V]) Pop() any {
+	old := *h
+	n := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	n.hidx = -1
+	return n
+}
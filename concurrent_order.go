@@ -0,0 +1,396 @@
+package ordereddict
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+)
+
+// concurrentEntry is a copy of an entry of a ConcurrentOrderedDict, with
+// its expiry time in Unix nanoseconds, or 0 if it never expires.
+type concurrentEntry[K comparable, V any] struct {
+	key     K
+	val     V
+	expires int64
+}
+
+// lockAll locks every shard in order and then the list, and removes the
+// entries that have expired, so that the list holds exactly the live
+// entries until unlockAll is called. Operations on the whole order use it.
+func (c *ConcurrentOrderedDict[K, V]) lockAll() {
+	for _, s := range c.shards {
+		s.mu.Lock()
+	}
+	c.listMu.Lock()
+	if !c.timed.Load() {
+		return
+	}
+	for curr := c.head.next; curr != c.tail; {
+		next := curr.next
+		if c.expired(curr) {
+			c.drop(c.shard(curr.key), curr)
+		}
+		curr = next
+	}
+}
+
+// unlockAll releases the locks taken by lockAll.
+func (c *ConcurrentOrderedDict[K, V]) unlockAll() {
+	c.listMu.Unlock()
+	for _, s := range c.shards {
+		s.mu.Unlock()
+	}
+}
+
+// list returns an iterator over the entries in order. It must be called
+// with every lock held.
+func (c *ConcurrentOrderedDict[K, V]) list() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for curr := c.head.next; curr != c.tail; curr = curr.next {
+			if !yield(curr.key, *curr.val.Load()) {
+				return
+			}
+		}
+	}
+}
+
+// entries copies the entries in order. It must be called with every lock
+// held.
+func (c *ConcurrentOrderedDict[K, V]) entries() []concurrentEntry[K, V] {
+	entries := make([]concurrentEntry[K, V], 0, c.len.Load())
+	for curr := c.head.next; curr != c.tail; curr = curr.next {
+		entries = append(entries, concurrentEntry[K, V]{curr.key, *curr.val.Load(), curr.expires.Load()})
+	}
+	return entries
+}
+
+// load adds entries at the end, keeping their expiry times. None of the
+// keys may exist. It must be called with every lock held.
+func (c *ConcurrentOrderedDict[K, V]) load(entries []concurrentEntry[K, V]) {
+	for _, e := range entries {
+		n := newConcurrentNode(e.key, e.val)
+		if e.expires != 0 {
+			c.timed.Store(true)
+			c.expireAt(n, e.expires)
+		}
+		c.shard(e.key).data[e.key] = n
+		c.linkAfter(n, c.tail.prev)
+	}
+	c.len.Add(int64(len(entries)))
+}
+
+// relink collects the nodes in order, lets reorder permute them and links
+// them back in the new order. It must be called with every lock held.
+func (c *ConcurrentOrderedDict[K, V]) relink(reorder func(nodes []*concurrentNode[K, V])) {
+	if c.len.Load() < 2 {
+		return
+	}
+	nodes := make([]*concurrentNode[K, V], 0, c.len.Load())
+	for curr := c.head.next; curr != c.tail; curr = curr.next {
+		nodes = append(nodes, curr)
+	}
+	reorder(nodes)
+
+	prev := c.head
+	for _, n := range nodes {
+		prev.next = n
+		n.prev = prev
+		prev = n
+	}
+	prev.next = c.tail
+	c.tail.prev = prev
+}
+
+// Swap exchanges the positions of two keys, returns false if either key
+// doesn't exist or both are the same key.
+func (c *ConcurrentOrderedDict[K, V]) Swap(a K, b K) bool {
+	if a == b {
+		return false
+	}
+	for {
+		nodeA, nodeB := c.lookup(a), c.lookup(b)
+		if nodeA == nil || nodeB == nil {
+			return false
+		}
+		c.listMu.Lock()
+		if !nodeA.removed && !nodeB.removed {
+			c.swap(nodeA, nodeB)
+			c.listMu.Unlock()
+			return true
+		}
+		c.listMu.Unlock()
+	}
+}
+
+// swap exchanges the positions of two distinct nodes. It must be called
+// with the list lock held.
+func (c *ConcurrentOrderedDict[K, V]) swap(nodeA, nodeB *concurrentNode[K, V]) {
+	if nodeB.next == nodeA {
+		nodeA, nodeB = nodeB, nodeA
+	}
+	if nodeA.next == nodeB {
+		c.unlink(nodeA)
+		c.linkAfter(nodeA, nodeB)
+		return
+	}
+	prevA, prevB := nodeA.prev, nodeB.prev
+	c.unlink(nodeA)
+	c.linkAfter(nodeA, prevB)
+	c.unlink(nodeB)
+	c.linkAfter(nodeB, prevA)
+}
+
+// Rotate moves the first n entries to the end of the order, keeping their
+// relative order. A negative n moves the last -n entries to the start
+// instead. n is taken modulo the length.
+func (c *ConcurrentOrderedDict[K, V]) Rotate(n int) {
+	c.lockAll()
+	defer c.unlockAll()
+	size := int(c.len.Load())
+	if size == 0 {
+		return
+	}
+	n %= size
+	if n < 0 {
+		n += size
+	}
+	if n <= size/2 {
+		for range n {
+			first := c.head.next
+			c.unlink(first)
+			c.linkAfter(first, c.tail.prev)
+		}
+		return
+	}
+	for range size - n {
+		last := c.tail.prev
+		c.unlink(last)
+		c.linkAfter(last, c.head)
+	}
+}
+
+// Reverse reverses the order of the entries in place.
+func (c *ConcurrentOrderedDict[K, V]) Reverse() {
+	c.lockAll()
+	defer c.unlockAll()
+	c.relink(slices.Reverse[[]*concurrentNode[K, V]])
+}
+
+// SortFunc reorders the dictionary so that its entries are sorted by cmp,
+// as OrderedDict.SortFunc does. cmp runs while every shard is locked, so it
+// must not call back into the dictionary.
+func (c *ConcurrentOrderedDict[K, V]) SortFunc(cmp func(a, b Entry[K, V]) int) {
+	c.sort(cmp, slices.SortFunc[[]*concurrentNode[K, V]])
+}
+
+// SortStableFunc is like SortFunc, but keeps entries that compare equal in
+// their current order.
+func (c *ConcurrentOrderedDict[K, V]) SortStableFunc(cmp func(a, b Entry[K, V]) int) {
+	c.sort(cmp, slices.SortStableFunc[[]*concurrentNode[K, V]])
+}
+
+// SortKeysConcurrent reorders c so that its keys are in ascending order. It
+// is the counterpart of SortKeys for a ConcurrentOrderedDict.
+func SortKeysConcurrent[K cmp.Ordered, V any](c *ConcurrentOrderedDict[K, V]) {
+	c.lockAll()
+	defer c.unlockAll()
+	c.relink(func(nodes []*concurrentNode[K, V]) {
+		slices.SortFunc(nodes, func(a, b *concurrentNode[K, V]) int {
+			return cmp.Compare(a.key, b.key)
+		})
+	})
+}
+
+func (c *ConcurrentOrderedDict[K, V]) sort(cmp func(a, b Entry[K, V]) int, sort func([]*concurrentNode[K, V], func(a, b *concurrentNode[K, V]) int)) {
+	c.lockAll()
+	defer c.unlockAll()
+	c.relink(func(nodes []*concurrentNode[K, V]) {
+		sort(nodes, func(a, b *concurrentNode[K, V]) int {
+			return cmp(Entry[K, V]{a.key, *a.val.Load()}, Entry[K, V]{b.key, *b.val.Load()})
+		})
+	})
+}
+
+// ReorderTo rearranges the dictionary so that its keys follow the order of
+// keys, as OrderedDict.ReorderTo does.
+func (c *ConcurrentOrderedDict[K, V]) ReorderTo(keys []K, opts ...ReorderOption) error {
+	var cfg reorderOptions
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	c.lockAll()
+	defer c.unlockAll()
+	seen, err := checkReorder(keys, cfg, int(c.len.Load()), func(key K) bool {
+		_, ok := c.shard(key).data[key]
+		return ok
+	}, c.list())
+	if err != nil {
+		return err
+	}
+
+	c.relink(func(nodes []*concurrentNode[K, V]) {
+		rest := make([]*concurrentNode[K, V], 0, len(nodes)-len(keys))
+		for _, n := range nodes {
+			if seen[n.key] == 0 {
+				rest = append(rest, n)
+			}
+		}
+		for i, key := range keys {
+			nodes[i] = c.shard(key).data[key]
+		}
+		copy(nodes[len(keys):], rest)
+	})
+	return nil
+}
+
+// RenameKey changes the key of an entry from old to new, keeping its
+// position, value and expiry, as OrderedDict.RenameKey does. It locks only
+// the shards of the two keys.
+func (c *ConcurrentOrderedDict[K, V]) RenameKey(old, new K) error {
+	c.expire()
+	i, j := c.shardIndex(old), c.shardIndex(new)
+	so, sn := c.shards[i], c.shards[j]
+
+	// Take the two shard locks in the same order as lockAll.
+	first, second := so, sn
+	if i > j {
+		first, second = sn, so
+	}
+	first.mu.Lock()
+	defer first.mu.Unlock()
+	if i != j {
+		second.mu.Lock()
+		defer second.mu.Unlock()
+	}
+
+	n := c.find(so, old)
+	if n == nil {
+		return ErrKeyNotFound
+	}
+	if old == new {
+		return nil
+	}
+	if c.find(sn, new) != nil {
+		return ErrKeyExists
+	}
+
+	// Iterators read keys without holding a lock, so the entry gets a new
+	// node in the same place rather than a new key.
+	r := newConcurrentNode(new, *n.val.Load())
+	c.listMu.Lock()
+	c.linkAfter(r, n.prev)
+	c.unlink(n)
+	n.removed = true
+	c.listMu.Unlock()
+	delete(so.data, old)
+	sn.data[new] = r
+	if expires := n.expires.Load(); expires != 0 {
+		c.expireAt(n, 0)
+		c.expireAt(r, expires)
+	}
+	return nil
+}
+
+// At returns the entry at position i in the order, returns false if i is
+// out of range. There is no position index, so At walks the order from the
+// start in O(i).
+func (c *ConcurrentOrderedDict[K, V]) At(i int) (K, V, bool) {
+	c.listMu.Lock()
+	defer c.listMu.Unlock()
+	if i < 0 {
+		return c.entry(c.tail)
+	}
+	n := c.skip(c.head.next, false)
+	for ; n != c.tail && i > 0; i-- {
+		n = c.skip(n.next, false)
+	}
+	return c.entry(n)
+}
+
+// IndexOf returns the position of a key in the order, returns false if key
+// doesn't exist. Like At, it walks the order in O(n).
+func (c *ConcurrentOrderedDict[K, V]) IndexOf(key K) (int, bool) {
+	for {
+		n := c.lookup(key)
+		if n == nil {
+			return -1, false
+		}
+		c.listMu.Lock()
+		if !n.removed {
+			i := 0
+			for curr := c.head.next; curr != n; curr = curr.next {
+				if !c.expired(curr) {
+					i++
+				}
+			}
+			c.listMu.Unlock()
+			return i, true
+		}
+		c.listMu.Unlock()
+	}
+}
+
+// Slice returns a new ConcurrentOrderedDict holding the entries at
+// positions [start, end) in order, as OrderedDict.Slice does. The returned
+// dictionary is independent of c and has no expiry settings.
+func (c *ConcurrentOrderedDict[K, V]) Slice(start, end int) *ConcurrentOrderedDict[K, V] {
+	c.lockAll()
+	size := int(c.len.Load())
+	start = min(max(start, 0), size)
+	end = min(max(end, start), size)
+	entries := make([]concurrentEntry[K, V], 0, end-start)
+	curr := c.head.next
+	for range start {
+		curr = curr.next
+	}
+	for range end - start {
+		entries = append(entries, concurrentEntry[K, V]{key: curr.key, val: *curr.val.Load()})
+		curr = curr.next
+	}
+	c.unlockAll()
+
+	out := NewConcurrent[K, V]()
+	out.lockAll()
+	out.load(entries)
+	out.unlockAll()
+	return out
+}
+
+// Immutable returns an immutable copy of the dictionary's current contents.
+func (c *ConcurrentOrderedDict[K, V]) Immutable() *ImmutableOrderedDict[K, V] {
+	c.lockAll()
+	defer c.unlockAll()
+	return buildImmutable(int(c.len.Load()), c.list())
+}
+
+// Snapshot returns a read-only view of the dictionary's current contents,
+// taken with every shard locked. Unlike OrderedDict.Snapshot, each snapshot
+// copies the entries in O(n). Entries in a snapshot never expire, and its
+// Clone method returns an OrderedDict with c's expiry settings.
+func (c *ConcurrentOrderedDict[K, V]) Snapshot() *Snapshot[K, V] {
+	c.lockAll()
+	defer c.unlockAll()
+	var expiry *hamtNode[K, int64]
+	c.expMu.Lock()
+	for _, n := range c.expiry {
+		expiry, _ = expiry.set(hamtHash(n.key), n.key, n.expires.Load(), 0)
+	}
+	c.expMu.Unlock()
+	m := buildImmutable(int(c.len.Load()), c.list())
+	return &Snapshot[K, V]{m: m, expiry: expiry, ttl: c.ttl, now: c.now}
+}
+
+// Clone returns a copy of c with the same expiry settings and expiry
+// times. The copy has no janitor.
+func (c *ConcurrentOrderedDict[K, V]) Clone() *ConcurrentOrderedDict[K, V] {
+	c.lockAll()
+	entries := c.entries()
+	c.unlockAll()
+
+	out := NewConcurrent[K, V](WithTTL(c.ttl), WithClock(c.now))
+	out.lockAll()
+	out.load(entries)
+	out.unlockAll()
+	return out
+}
@@ -0,0 +1,776 @@
+package ordereddict
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConcurrentBasic(t *testing.T) {
+	c := NewConcurrent[string, int]()
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Set("c", 3)
+	c.Set("a", 10)
+
+	if val, ok := c.Get("a"); !ok || val != 10 {
+		t.Errorf("expected a=10, got %d", val)
+	}
+	if !c.Has("b") || c.Has("missing") {
+		t.Error("unexpected Has result")
+	}
+	if c.Len() != 3 {
+		t.Errorf("expected len=3, got %d", c.Len())
+	}
+	if !slices.Equal(c.Keys(), []string{"a", "b", "c"}) {
+		t.Errorf("expected [a b c], got %v", c.Keys())
+	}
+	if !slices.Equal(c.Values(), []int{10, 2, 3}) {
+		t.Errorf("expected [10 2 3], got %v", c.Values())
+	}
+
+	if val, ok := c.Delete("b"); !ok || val != 2 {
+		t.Errorf("expected to delete b=2, got %d", val)
+	}
+	if c.Remove("b") {
+		t.Error("expected second removal to fail")
+	}
+	if c.String() != "ConcurrentOrderedDict[a:10 c:3]" {
+		t.Errorf("unexpected String %q", c.String())
+	}
+
+	c.Clear()
+	if c.Len() != 0 || len(c.Keys()) != 0 {
+		t.Error("expected empty dict after Clear")
+	}
+	c.Set("x", 1)
+	if !slices.Equal(c.Keys(), []string{"x"}) {
+		t.Errorf("expected [x], got %v", c.Keys())
+	}
+}
+
+func TestConcurrentReorder(t *testing.T) {
+	c := NewConcurrent[string, int]()
+	for i, key := range []string{"a", "b", "c", "d"} {
+		c.Set(key, i)
+	}
+
+	c.MoveToEnd("a")       // b c d a
+	c.MoveToStart("d")     // d b c a
+	c.MoveAfter("b", "a")  // d c a b
+	c.MoveBefore("a", "d") // a d c b
+	if c.MoveAfter("a", "a") || c.MoveToEnd("missing") || c.MoveBefore("a", "missing") {
+		t.Error("expected invalid moves to fail")
+	}
+	if !slices.Equal(c.Keys(), []string{"a", "d", "c", "b"}) {
+		t.Errorf("expected [a d c b], got %v", c.Keys())
+	}
+
+	c.SetFirst("e", 5)       // e a d c b
+	c.SetAfter("d", "b", 20) // e a d b c
+	c.SetBefore("e", "f", 6) // f e a d b c
+	if c.SetAfter("missing", "g", 7) {
+		t.Error("expected SetAfter with missing anchor to fail")
+	}
+	if !slices.Equal(c.Keys(), []string{"f", "e", "a", "d", "b", "c"}) {
+		t.Errorf("expected [f e a d b c], got %v", c.Keys())
+	}
+	if val, _ := c.Get("b"); val != 20 {
+		t.Errorf("expected b=20, got %d", val)
+	}
+	if c.Len() != 6 {
+		t.Errorf("expected len=6, got %d", c.Len())
+	}
+
+	var backward []string
+	for k := range c.Backward() {
+		backward = append(backward, k)
+	}
+	if !slices.Equal(backward, []string{"c", "b", "d", "a", "e", "f"}) {
+		t.Errorf("unexpected Backward order %v", backward)
+	}
+}
+
+func TestConcurrentWholeOrder(t *testing.T) {
+	c := NewConcurrent[string, int]()
+	for i, key := range []string{"d", "b", "a", "c"} {
+		c.Set(key, i)
+	}
+
+	if !c.Swap("d", "c") || c.Swap("d", "d") || c.Swap("d", "missing") {
+		t.Error("unexpected Swap result")
+	}
+	if !slices.Equal(c.Keys(), []string{"c", "b", "a", "d"}) {
+		t.Errorf("expected [c b a d] after Swap, got %v", c.Keys())
+	}
+	c.Rotate(1)
+	if !slices.Equal(c.Keys(), []string{"b", "a", "d", "c"}) {
+		t.Errorf("expected [b a d c] after Rotate, got %v", c.Keys())
+	}
+	c.Rotate(-3)
+	if !slices.Equal(c.Keys(), []string{"a", "d", "c", "b"}) {
+		t.Errorf("expected [a d c b] after Rotate(-3), got %v", c.Keys())
+	}
+	c.Reverse()
+	if !slices.Equal(c.Keys(), []string{"b", "c", "d", "a"}) {
+		t.Errorf("expected [b c d a] after Reverse, got %v", c.Keys())
+	}
+	SortKeysConcurrent(c)
+	if !slices.Equal(c.Keys(), []string{"a", "b", "c", "d"}) {
+		t.Errorf("expected [a b c d] after SortKeysConcurrent, got %v", c.Keys())
+	}
+	c.SortStableFunc(func(a, b Entry[string, int]) int { return a.Value - b.Value })
+	if !slices.Equal(c.Keys(), []string{"d", "b", "a", "c"}) {
+		t.Errorf("expected [d b a c] after SortStableFunc, got %v", c.Keys())
+	}
+	c.SortFunc(func(a, b Entry[string, int]) int { return b.Value - a.Value })
+	if !slices.Equal(c.Keys(), []string{"c", "a", "b", "d"}) {
+		t.Errorf("expected [c a b d] after SortFunc, got %v", c.Keys())
+	}
+
+	if err := c.ReorderTo([]string{"b", "d"}); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(c.Keys(), []string{"b", "d", "c", "a"}) {
+		t.Errorf("expected [b d c a] after ReorderTo, got %v", c.Keys())
+	}
+	var rerr *ReorderError[string]
+	if err := c.ReorderTo([]string{"a", "x"}, RejectMissing()); !errors.As(err, &rerr) ||
+		!slices.Equal(rerr.Unknown, []string{"x"}) || !slices.Equal(rerr.Missing, []string{"b", "d", "c"}) {
+		t.Errorf("unexpected ReorderTo error %v", err)
+	}
+
+	if err := c.RenameKey("d", "e"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.RenameKey("x", "y"); err != ErrKeyNotFound {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+	if err := c.RenameKey("a", "b"); err != ErrKeyExists {
+		t.Errorf("expected ErrKeyExists, got %v", err)
+	}
+	if !slices.Equal(c.Keys(), []string{"b", "e", "c", "a"}) || c.Has("d") || c.Len() != 4 {
+		t.Errorf("expected [b e c a] after RenameKey, got %v", c.Keys())
+	}
+	if val, _ := c.Get("e"); val != 0 {
+		t.Errorf("expected e to keep d's value, got %d", val)
+	}
+}
+
+func TestConcurrentPositions(t *testing.T) {
+	clock := newFakeClock()
+	c := NewConcurrent[string, int](WithClock(clock.Now))
+	for i, key := range []string{"a", "b", "c", "d"} {
+		c.Set(key, i)
+	}
+	c.SetWithTTL("b", 1, time.Second)
+	clock.Advance(time.Second)
+
+	if k, v, ok := c.At(1); !ok || k != "c" || v != 2 {
+		t.Errorf("At(1): expected c:2 with b expired, got %s:%d", k, v)
+	}
+	if _, _, ok := c.At(3); ok {
+		t.Error("expected At(3) to be out of range")
+	}
+	if _, _, ok := c.At(-1); ok {
+		t.Error("expected At(-1) to be out of range")
+	}
+	if i, ok := c.IndexOf("d"); !ok || i != 2 {
+		t.Errorf("IndexOf(d): expected 2, got %d", i)
+	}
+	if i, ok := c.IndexOf("b"); ok || i != -1 {
+		t.Errorf("IndexOf(b): expected -1, got %d", i)
+	}
+
+	s := c.Slice(1, 10)
+	if !slices.Equal(s.Keys(), []string{"c", "d"}) {
+		t.Errorf("expected Slice [c d], got %v", s.Keys())
+	}
+	s.Set("e", 4)
+	if c.Has("e") {
+		t.Error("expected the slice to be independent")
+	}
+}
+
+func TestConcurrentCopies(t *testing.T) {
+	clock := newFakeClock()
+	c := NewConcurrent[string, int](WithTTL(time.Second), WithClock(clock.Now))
+	c.Set("a", 1)
+	c.SetWithTTL("b", 2, 0)
+
+	snap := c.Snapshot()
+	imm := c.Immutable()
+	clone := c.Clone()
+	c.Set("a", 10)
+	c.Delete("b")
+
+	if val, _ := snap.Get("a"); val != 1 || !slices.Equal(snap.Keys(), []string{"a", "b"}) {
+		t.Errorf("expected the snapshot to keep a:1 b:2, got %v", snap)
+	}
+	if val, _ := imm.Get("a"); val != 1 || imm.Len() != 2 {
+		t.Errorf("expected the immutable copy to keep a:1 b:2, got %v", imm)
+	}
+	if val, _ := clone.Get("a"); val != 1 || !slices.Equal(clone.Keys(), []string{"a", "b"}) {
+		t.Errorf("expected the clone to keep a:1 b:2, got %v", clone)
+	}
+
+	// The copies keep a's expiry time; b never expires.
+	fromSnap := snap.Clone()
+	clock.Advance(time.Second)
+	if clone.Has("a") || fromSnap.Has("a") || !clone.Has("b") || !fromSnap.Has("b") {
+		t.Error("expected a to expire from the copies and b to remain")
+	}
+	clone.Set("c", 3)
+	clock.Advance(time.Second)
+	if clone.Has("c") {
+		t.Error("expected the clone to keep the default TTL")
+	}
+
+	other := NewConcurrent[string, int]()
+	other.Set("x", 1)
+	other.Set("y", 2)
+	c.Replace(other)
+	if !slices.Equal(c.Keys(), []string{"x", "y"}) {
+		t.Errorf("expected [x y] after Replace, got %v", c.Keys())
+	}
+	clock.Advance(time.Second)
+	if c.Len() != 0 {
+		t.Error("expected replaced entries to take the default TTL")
+	}
+	if other.Len() != 2 {
+		t.Error("expected Replace to leave the source unchanged")
+	}
+	c.Replace(nil)
+	if c.Len() != 0 {
+		t.Error("expected Replace(nil) to empty the dict")
+	}
+}
+
+func TestConcurrentPop(t *testing.T) {
+	c := NewConcurrent[string, int]()
+	c.Set("a", 1)
+	c.Set("b", 2)
+
+	if k, v, ok := c.First(); !ok || k != "a" || v != 1 {
+		t.Errorf("First: expected a:1, got %s:%d", k, v)
+	}
+	if k, v, ok := c.PopLast(); !ok || k != "b" || v != 2 {
+		t.Errorf("PopLast: expected b:2, got %s:%d", k, v)
+	}
+	if k, _, ok := c.PopFirst(); !ok || k != "a" {
+		t.Errorf("PopFirst: expected a, got %s", k)
+	}
+	if _, _, ok := c.PopFirst(); ok {
+		t.Error("expected PopFirst on empty dict to fail")
+	}
+	if _, _, ok := c.Last(); ok {
+		t.Error("expected Last on empty dict to fail")
+	}
+}
+
+func TestConcurrentPopOrder(t *testing.T) {
+	// Keys are pushed to the front in increasing order, so PopFirst must
+	// always return the largest key present when it is called.
+	c := NewConcurrent[int, int]()
+	var pushed atomic.Int64
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 20000 {
+			c.SetFirst(i, i)
+			pushed.Store(int64(i + 1))
+		}
+	}()
+
+	popped := make(map[int]bool)
+	for {
+		p := int(pushed.Load())
+		k, _, ok := c.PopFirst()
+		if !ok {
+			select {
+			case <-done:
+				if c.Len() == 0 {
+					return
+				}
+			default:
+			}
+			continue
+		}
+		if k < p-1 && !popped[p-1] {
+			t.Fatalf("PopFirst returned %d while %d was at the front", k, p-1)
+		}
+		popped[k] = true
+	}
+}
+
+func TestConcurrentCompute(t *testing.T) {
+	c := NewConcurrent[string, int]()
+
+	if val, loaded := c.GetOrSet("a", 1); loaded || val != 1 {
+		t.Errorf("expected a=1 to be set, got %d", val)
+	}
+	if val, loaded := c.GetOrSet("a", 2); !loaded || val != 1 {
+		t.Errorf("expected a=1 to be loaded, got %d", val)
+	}
+	if c.SetIfAbsent("a", 3) || !c.SetIfAbsent("b", 3) {
+		t.Error("unexpected SetIfAbsent result")
+	}
+
+	c.Compute("a", func(old int, _ bool) (int, bool) { return old + 1, true })
+	c.Compute("b", func(int, bool) (int, bool) { return 0, false })
+	c.Compute("c", func(old int, exists bool) (int, bool) { return 7, !exists })
+
+	if !slices.Equal(c.Keys(), []string{"a", "c"}) || !slices.Equal(c.Values(), []int{2, 7}) {
+		t.Errorf("unexpected contents %v", c)
+	}
+}
+
+func TestConcurrentNextPrev(t *testing.T) {
+	c := NewConcurrent[string, int]()
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Set("c", 3)
+
+	if k, v, ok := c.Next("a"); !ok || k != "b" || v != 2 {
+		t.Errorf("Next(a): expected b:2, got %s:%d", k, v)
+	}
+	if k, _, ok := c.Prev("c"); !ok || k != "b" {
+		t.Errorf("Prev(c): expected b, got %s", k)
+	}
+	if _, _, ok := c.Next("c"); ok {
+		t.Error("expected Next of the last key to fail")
+	}
+	if _, _, ok := c.Prev("a"); ok {
+		t.Error("expected Prev of the first key to fail")
+	}
+	if _, _, ok := c.Next("missing"); ok {
+		t.Error("expected Next of a missing key to fail")
+	}
+}
+
+func TestConcurrentBatch(t *testing.T) {
+	src := New[string, int]()
+	for i, key := range []string{"a", "b", "c", "d"} {
+		src.Set(key, i)
+	}
+	c := NewConcurrent[string, int]()
+	c.SetMany(src.All())
+	c.SetMany(c.All())
+
+	if !slices.Equal(c.Keys(), []string{"a", "b", "c", "d"}) {
+		t.Errorf("expected [a b c d], got %v", c.Keys())
+	}
+	vals, found := c.GetMany("c", "missing", "a")
+	if !slices.Equal(vals, []int{2, 0, 0}) {
+		t.Errorf("expected [2 0 0], got %v", vals)
+	}
+	if !slices.Equal(found, []bool{true, false, true}) {
+		t.Errorf("expected [true false true], got %v", found)
+	}
+	if n := c.DeleteMany("a", "missing", "a"); n != 1 {
+		t.Errorf("expected 1 deletion, got %d", n)
+	}
+	if k, _, ok := c.PopItem(true); !ok || k != "d" {
+		t.Errorf("expected to pop d, got %s", k)
+	}
+
+	keys := slices.Collect(c.KeysBackward())
+	vals = slices.Collect(c.ValuesBackward())
+	if !slices.Equal(keys, []string{"c", "b"}) || !slices.Equal(vals, []int{2, 1}) {
+		t.Errorf("expected [c b] [2 1], got %v %v", keys, vals)
+	}
+}
+
+func TestConcurrentMerge(t *testing.T) {
+	c := NewConcurrent[string, int]()
+	c.Set("a", 1)
+	c.Set("b", 2)
+	other := NewConcurrent[string, int]()
+	other.Set("a", 10)
+	other.Set("c", 3)
+
+	c.MergeWith(other, func(_ string, old, new int) int { return old + new })
+	if !slices.Equal(c.Keys(), []string{"a", "b", "c"}) || !slices.Equal(c.Values(), []int{11, 2, 3}) {
+		t.Errorf("unexpected contents %v", c)
+	}
+	c.MergeToEnd(other)
+	if !slices.Equal(c.Keys(), []string{"b", "a", "c"}) {
+		t.Errorf("expected [b a c], got %v", c.Keys())
+	}
+	c.Merge(c)
+	c.Merge(nil)
+	if c.Len() != 3 {
+		t.Errorf("expected len=3, got %d", c.Len())
+	}
+}
+
+func TestConcurrentMergeBothWays(t *testing.T) {
+	a := NewConcurrent[int, int]()
+	b := NewConcurrent[int, int]()
+	for i := range 100 {
+		a.Set(i, i)
+		b.Set(i+50, i)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		a.Merge(b)
+	}()
+	go func() {
+		defer wg.Done()
+		b.Merge(a)
+	}()
+	wg.Wait()
+
+	if a.Len() != 150 || b.Len() < 100 {
+		t.Errorf("unexpected lengths %d and %d", a.Len(), b.Len())
+	}
+}
+
+func TestConcurrentCompareAndSwap(t *testing.T) {
+	c := NewConcurrent[string, int]()
+	c.Set("a", 1)
+
+	if CompareAndSwapConcurrent(c, "a", 5, 10) {
+		t.Error("expected swap with wrong old value to fail")
+	}
+	if !CompareAndSwapConcurrent(c, "a", 1, 10) {
+		t.Error("expected swap to succeed")
+	}
+	if CompareAndSwapConcurrent(c, "missing", 0, 1) {
+		t.Error("expected swap of a missing key to fail")
+	}
+	if val, _ := c.Get("a"); val != 10 {
+		t.Errorf("expected a=10, got %d", val)
+	}
+}
+
+func TestConcurrentJSON(t *testing.T) {
+	c := NewConcurrent[string, int]()
+	c.Set("z", 1)
+	c.Set("a", 2)
+
+	b, err := json.Marshal(c)
+	if err != nil || string(b) != `{"z":1,"a":2}` {
+		t.Fatalf("unexpected JSON %s (%v)", b, err)
+	}
+
+	d := NewConcurrent[string, int]()
+	d.Set("old", 0)
+	if err := json.Unmarshal([]byte(`{"b":1,"a":2,"b":3}`), d); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(d.Keys(), []string{"b", "a"}) || !slices.Equal(d.Values(), []int{3, 2}) {
+		t.Errorf("unexpected contents %v", d)
+	}
+	if d.Has("old") || d.Len() != 2 {
+		t.Error("expected Unmarshal to replace the contents")
+	}
+
+	if err := json.Unmarshal([]byte(`[1]`), d); err == nil {
+		t.Error("expected an error for a JSON array")
+	}
+	if err := json.Unmarshal([]byte(`null`), d); err != nil || d.Len() != 2 {
+		t.Errorf("expected null to leave the dict unchanged (%v)", err)
+	}
+}
+
+func TestConcurrentTTL(t *testing.T) {
+	clock := newFakeClock()
+	c := NewConcurrent[string, int](WithTTL(2*time.Second), WithClock(clock.Now))
+	c.SetWithTTL("a", 1, time.Second)
+	c.Set("b", 2)
+	c.SetWithTTL("c", 3, 0)
+
+	clock.Advance(time.Second)
+	if c.Has("a") {
+		t.Error("expected a to expire")
+	}
+	if _, ok := c.Get("a"); ok {
+		t.Error("expected Get of an expired key to fail")
+	}
+	if k, _, ok := c.First(); !ok || k != "b" {
+		t.Errorf("expected First to skip a, got %s", k)
+	}
+	if !slices.Equal(c.Keys(), []string{"b", "c"}) || c.Len() != 2 {
+		t.Errorf("expected [b c], got %v", c.Keys())
+	}
+
+	c.Set("b", 20) // refreshes the default TTL
+	clock.Advance(time.Second)
+	if val, ok := c.Get("b"); !ok || val != 20 {
+		t.Error("expected Set to refresh b's TTL")
+	}
+	if !c.SetIfAbsent("a", 10) {
+		t.Error("expected an expired key to count as absent")
+	}
+
+	clock.Advance(2 * time.Second)
+	if !slices.Equal(c.Keys(), []string{"c"}) || c.Len() != 1 {
+		t.Errorf("expected only c to remain, got %v", c.Keys())
+	}
+	if len(c.expiry) != 0 {
+		t.Errorf("expected an empty expiry heap, got %d", len(c.expiry))
+	}
+}
+
+func TestConcurrentExpiryHeapSize(t *testing.T) {
+	c := NewConcurrent[int, int](WithTTL(time.Hour))
+	for i := range 10000 {
+		c.Set(0, i)
+	}
+	for i := range 1000 {
+		c.Set(i+1, i)
+		c.Delete(i + 1)
+	}
+	c.SetWithTTL(0, 0, 0)
+	if len(c.expiry) != 0 {
+		t.Errorf("expected an empty expiry heap, got %d deadlines", len(c.expiry))
+	}
+}
+
+func TestConcurrentJanitor(t *testing.T) {
+	c := NewConcurrent[string, int](WithJanitor(time.Millisecond))
+	defer c.Close()
+	c.SetWithTTL("a", 1, time.Millisecond)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for c.len.Load() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("janitor did not reclaim the expired entry")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := c.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConcurrentTTLStress(t *testing.T) {
+	clock := newFakeClock()
+	c := NewConcurrent[int, int](WithClock(clock.Now))
+	var wg sync.WaitGroup
+
+	for g := range 8 {
+		wg.Add(1)
+		go func(seed uint64) {
+			defer wg.Done()
+			r := rand.New(rand.NewPCG(seed, seed))
+			for range 2000 {
+				key := r.IntN(64)
+				switch r.IntN(8) {
+				case 0, 1:
+					c.SetWithTTL(key, key, time.Duration(r.IntN(3))*time.Second)
+				case 2:
+					c.Delete(key)
+				case 3:
+					c.PopFirst()
+				case 4:
+					c.Next(key)
+				case 5:
+					clock.Advance(100 * time.Millisecond)
+				case 6:
+					c.RenameKey(key, r.IntN(64))
+				case 7:
+					c.Reverse()
+				}
+			}
+		}(uint64(g))
+	}
+	wg.Wait()
+
+	clock.Advance(3 * time.Second)
+	keys := c.Keys()
+	if len(keys) != c.Len() {
+		t.Fatalf("list has %d keys, len is %d", len(keys), c.Len())
+	}
+	for _, key := range keys {
+		if n := c.lookup(key); n == nil || n.expires.Load() != 0 {
+			t.Fatalf("key %d should have expired", key)
+		}
+	}
+}
+
+func TestConcurrentIterateAndModify(t *testing.T) {
+	c := NewConcurrent[int, int]()
+	for i := range 5 {
+		c.Set(i, i)
+	}
+
+	for k := range c.KeysSeq() {
+		c.Delete(k)
+		c.Set(k+100, k)
+	}
+	if c.Len() != 5 {
+		t.Errorf("expected len=5, got %d", c.Len())
+	}
+}
+
+func TestConcurrentStress(t *testing.T) {
+	c := NewConcurrent[int, int]()
+	var wg sync.WaitGroup
+
+	for g := range 16 {
+		wg.Add(1)
+		go func(seed uint64) {
+			defer wg.Done()
+			r := rand.New(rand.NewPCG(seed, seed))
+			for range 2000 {
+				key := r.IntN(64)
+				switch r.IntN(15) {
+				case 0, 1, 2:
+					c.Set(key, key)
+				case 3:
+					c.Delete(key)
+				case 4:
+					c.MoveToStart(key)
+				case 5:
+					c.MoveAfter(key, r.IntN(64))
+				case 6:
+					c.SetBefore(r.IntN(64), key, key)
+				case 7:
+					c.PopFirst()
+				case 8:
+					c.Compute(key, func(old int, _ bool) (int, bool) { return key, true })
+				case 9:
+					for range c.All() {
+					}
+				case 10:
+					c.Swap(key, r.IntN(64))
+				case 11:
+					c.Rotate(r.IntN(64) - 32)
+				case 12:
+					SortKeysConcurrent(c)
+				case 13:
+					c.IndexOf(key)
+				case 14:
+					c.Snapshot()
+				}
+			}
+		}(uint64(g))
+	}
+	wg.Wait()
+
+	// The order and the shards must agree.
+	keys := c.Keys()
+	if len(keys) != c.Len() {
+		t.Fatalf("list has %d keys, len is %d", len(keys), c.Len())
+	}
+	seen := make(map[int]bool)
+	for _, key := range keys {
+		if seen[key] {
+			t.Fatalf("key %d appears twice", key)
+		}
+		seen[key] = true
+		if val, ok := c.Get(key); !ok || val != key {
+			t.Fatalf("key %d in list but Get returned %d/%v", key, val, ok)
+		}
+	}
+	for key := range 64 {
+		if c.Has(key) != seen[key] {
+			t.Fatalf("key %d: Has=%v but in list=%v", key, c.Has(key), seen[key])
+		}
+	}
+}
+
+// The parallel benchmarks compare ConcurrentOrderedDict with OrderedDict on
+// a read-heavy mix (90% Get, 10% Set of existing keys) and on inserts.
+
+func BenchmarkOrderedDictParallelReadMostly(b *testing.B) {
+	od := New[int, int]()
+	for i := range 10000 {
+		od.Set(i, i)
+	}
+
+	b.RunParallel(func(pb *testing.PB) {
+		r := rand.New(rand.NewPCG(rand.Uint64(), 0))
+		for pb.Next() {
+			key := r.IntN(10000)
+			if r.IntN(10) == 0 {
+				od.Set(key, key)
+			} else {
+				od.Get(key)
+			}
+		}
+	})
+}
+
+func BenchmarkConcurrentParallelReadMostly(b *testing.B) {
+	c := NewConcurrent[int, int]()
+	for i := range 10000 {
+		c.Set(i, i)
+	}
+
+	b.RunParallel(func(pb *testing.PB) {
+		r := rand.New(rand.NewPCG(rand.Uint64(), 0))
+		for pb.Next() {
+			key := r.IntN(10000)
+			if r.IntN(10) == 0 {
+				c.Set(key, key)
+			} else {
+				c.Get(key)
+			}
+		}
+	})
+}
+
+func BenchmarkOrderedDictParallelGet(b *testing.B) {
+	od := New[int, int]()
+	for i := range 10000 {
+		od.Set(i, i)
+	}
+
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			od.Get(i % 10000)
+			i++
+		}
+	})
+}
+
+func BenchmarkConcurrentParallelGet(b *testing.B) {
+	c := NewConcurrent[int, int]()
+	for i := range 10000 {
+		c.Set(i, i)
+	}
+
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			c.Get(i % 10000)
+			i++
+		}
+	})
+}
+
+func BenchmarkOrderedDictParallelInsertDelete(b *testing.B) {
+	od := New[int, int]()
+	b.RunParallel(func(pb *testing.PB) {
+		r := rand.New(rand.NewPCG(rand.Uint64(), 0))
+		for pb.Next() {
+			key := r.IntN(100000)
+			od.Set(key, key)
+			od.Delete(key)
+		}
+	})
+}
+
+func BenchmarkConcurrentParallelInsertDelete(b *testing.B) {
+	c := NewConcurrent[int, int]()
+	b.RunParallel(func(pb *testing.PB) {
+		r := rand.New(rand.NewPCG(rand.Uint64(), 0))
+		for pb.Next() {
+			key := r.IntN(100000)
+			c.Set(key, key)
+			c.Delete(key)
+		}
+	})
+}
@@ -0,0 +1,191 @@
+package ordereddict
+
+import (
+	"hash/maphash"
+	"math/bits"
+)
+
+// The key map of ImmutableOrderedDict is a hash array mapped trie: a
+// 32-way trie over the bits of each key's hash. Each node only allocates
+// slots for the children it has, and updates copy the nodes along the path
+// to the changed slot, sharing everything else with the previous version.
+
+const (
+	hamtBits = 5
+	hamtMask = 1<<hamtBits - 1
+)
+
+// hamtSeed seeds the hash of every ImmutableOrderedDict, so versions derived
+// from one another can share nodes.
+var hamtSeed = maphash.MakeSeed()
+
+// hamtNode is an interior node of the trie. Each slot holds either a child
+// node or a leaf.
+type hamtNode[K comparable, E any] struct {
+	bitmap uint32
+	slots  []hamtSlot[K, E]
+}
+
+type hamtSlot[K comparable, E any] struct {
+	child *hamtNode[K, E]
+	leaf  *hamtLeaf[K, E]
+}
+
+// hamtLeaf holds the entries whose keys share a full hash, which is almost
+// always a single entry.
+type hamtLeaf[K comparable, E any] struct {
+	hash    uint64
+	entries []hamtEntry[K, E]
+}
+
+type hamtEntry[K comparable, E any] struct {
+	key K
+	val E
+}
+
+func hamtHash[K comparable](key K) uint64 {
+	return maphash.Comparable(hamtSeed, key)
+}
+
+// slot returns the index of the slot for hash at shift and whether it is
+// occupied.
+func (h *hamtNode[K, E]) slot(hash uint64, shift uint) (uint32, int, bool) {
+	bit := uint32(1) << ((hash >> shift) & hamtMask)
+	i := bits.OnesCount32(h.bitmap & (bit - 1))
+	return bit, i, h.bitmap&bit != 0
+}
+
+// get returns the value stored for key.
+func (h *hamtNode[K, E]) get(hash uint64, key K) (E, bool) {
+	for shift := uint(0); h != nil; shift += hamtBits {
+		_, i, ok := h.slot(hash, shift)
+		if !ok {
+			break
+		}
+		s := h.slots[i]
+		if s.child != nil {
+			h = s.child
+			continue
+		}
+		if s.leaf.hash == hash {
+			for _, e := range s.leaf.entries {
+				if e.key == key {
+					return e.val, true
+				}
+			}
+		}
+		break
+	}
+	var zero E
+	return zero, false
+}
+
+// set returns a copy of the trie with key set to val and reports whether
+// key was added.
+func (h *hamtNode[K, E]) set(hash uint64, key K, val E, shift uint) (*hamtNode[K, E], bool) {
+	if h == nil {
+		h = &hamtNode[K, E]{}
+	}
+	bit, i, ok := h.slot(hash, shift)
+	if !ok {
+		leaf := &hamtLeaf[K, E]{hash: hash, entries: []hamtEntry[K, E]{{key, val}}}
+		c := &hamtNode[K, E]{bitmap: h.bitmap | bit, slots: make([]hamtSlot[K, E], len(h.slots)+1)}
+		copy(c.slots, h.slots[:i])
+		c.slots[i] = hamtSlot[K, E]{leaf: leaf}
+		copy(c.slots[i+1:], h.slots[i:])
+		return c, true
+	}
+
+	var s hamtSlot[K, E]
+	added := false
+	switch old := h.slots[i]; {
+	case old.child != nil:
+		s.child, added = old.child.set(hash, key, val, shift+hamtBits)
+	case old.leaf.hash == hash:
+		s.leaf, added = old.leaf.set(key, val)
+	default:
+		// Push the existing leaf down a level; the hashes differ, so they
+		// part ways within the remaining bits.
+		child := &hamtNode[K, E]{
+			bitmap: 1 << ((old.leaf.hash >> (shift + hamtBits)) & hamtMask),
+			slots:  []hamtSlot[K, E]{old},
+		}
+		s.child, added = child.set(hash, key, val, shift+hamtBits)
+	}
+	c := &hamtNode[K, E]{bitmap: h.bitmap, slots: append([]hamtSlot[K, E](nil), h.slots...)}
+	c.slots[i] = s
+	return c, added
+}
+
+// set returns a copy of the leaf with key set to val and reports whether
+// key was added.
+func (l *hamtLeaf[K, E]) set(key K, val E) (*hamtLeaf[K, E], bool) {
+	c := &hamtLeaf[K, E]{hash: l.hash, entries: append([]hamtEntry[K, E](nil), l.entries...)}
+	for i := range c.entries {
+		if c.entries[i].key == key {
+			c.entries[i].val = val
+			return c, false
+		}
+	}
+	c.entries = append(c.entries, hamtEntry[K, E]{key, val})
+	return c, true
+}
+
+// delete returns a copy of the trie without key, or nil if it becomes
+// empty, and reports whether key was present.
+func (h *hamtNode[K, E]) delete(hash uint64, key K, shift uint) (*hamtNode[K, E], bool) {
+	if h == nil {
+		return nil, false
+	}
+	bit, i, ok := h.slot(hash, shift)
+	if !ok {
+		return h, false
+	}
+
+	var s hamtSlot[K, E]
+	switch old := h.slots[i]; {
+	case old.child != nil:
+		child, removed := old.child.delete(hash, key, shift+hamtBits)
+		if !removed {
+			return h, false
+		}
+		// Pull a lone leaf back up so the trie stays shallow.
+		if child != nil && len(child.slots) == 1 && child.slots[0].leaf != nil {
+			s = child.slots[0]
+		} else if child != nil {
+			s.child = child
+		}
+	case old.leaf.hash == hash:
+		j := -1
+		for k, e := range old.leaf.entries {
+			if e.key == key {
+				j = k
+				break
+			}
+		}
+		if j < 0 {
+			return h, false
+		}
+		if len(old.leaf.entries) > 1 {
+			entries := make([]hamtEntry[K, E], 0, len(old.leaf.entries)-1)
+			entries = append(entries, old.leaf.entries[:j]...)
+			entries = append(entries, old.leaf.entries[j+1:]...)
+			s.leaf = &hamtLeaf[K, E]{hash: hash, entries: entries}
+		}
+	default:
+		return h, false
+	}
+
+	if s.child != nil || s.leaf != nil {
+		c := &hamtNode[K, E]{bitmap: h.bitmap, slots: append([]hamtSlot[K, E](nil), h.slots...)}
+		c.slots[i] = s
+		return c, true
+	}
+	if len(h.slots) == 1 {
+		return nil, true
+	}
+	c := &hamtNode[K, E]{bitmap: h.bitmap &^ bit, slots: make([]hamtSlot[K, E], 0, len(h.slots)-1)}
+	c.slots = append(c.slots, h.slots[:i]...)
+	c.slots = append(c.slots, h.slots[i+1:]...)
+	return c, true
+}
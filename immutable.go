@@ -0,0 +1,522 @@
+package ordereddict
+
+import (
+	"fmt"
+	"iter"
+	"math"
+	"math/rand/v2"
+	"strings"
+)
+
+// ImmutableOrderedDict is a persistent ordered dictionary. Methods that
+// change it return a new version and leave the receiver untouched, so a
+// version can be read from any number of goroutines without locking.
+// Versions share most of their structure: an update copies O(log n) nodes.
+//
+// Keys are held in a hash array mapped trie and the order in a treap keyed
+// by order labels. Labels are spaced out so that moves rarely need to
+// renumber the entries; when they do, the move costs O(n).
+//
+// The zero value is an empty dictionary ready to use.
+type ImmutableOrderedDict[K comparable, V any] struct {
+	keys  *hamtNode[K, immutableEntry[V]]
+	order *orderNode[K, V]
+	len   int
+}
+
+// immutableEntry is the key map's record of an entry.
+type immutableEntry[V any] struct {
+	label uint64
+	val   V
+}
+
+// orderNode is a node of the persistent treap that holds the order.
+type orderNode[K comparable, V any] struct {
+	label uint64
+	prio  uint32
+	key   K
+	val   V
+	left  *orderNode[K, V]
+	right *orderNode[K, V]
+}
+
+// labelGap is the distance between the labels of adjacent entries after
+// they are numbered.
+const labelGap = 1 << 20
+
+// NewImmutable creates a new empty ImmutableOrderedDict.
+func NewImmutable[K comparable, V any]() *ImmutableOrderedDict[K, V] {
+	return &ImmutableOrderedDict[K, V]{}
+}
+
+// Immutable returns an ImmutableOrderedDict with the dictionary's current
+// contents.
+func (o *OrderedDict[K, V]) Immutable() *ImmutableOrderedDict[K, V] {
+	excl := o.rlock()
+	defer o.runlock(excl)
+	return buildImmutable(o.s.len, o.s.all())
+}
+
+// Mutable returns a new OrderedDict with the same contents.
+func (m *ImmutableOrderedDict[K, V]) Mutable() *OrderedDict[K, V] {
+	out := NewWithCapacity[K, V](m.len)
+	for key, val := range m.All() {
+		out.set(key, val, 0)
+	}
+	return out
+}
+
+// buildImmutable builds a dictionary of n entries from seq, numbering them
+// with evenly spaced labels centred in the label space.
+func buildImmutable[K comparable, V any](n int, seq iter.Seq2[K, V]) *ImmutableOrderedDict[K, V] {
+	m := &ImmutableOrderedDict[K, V]{}
+	label := uint64(math.MaxUint64/2) - uint64(n/2)*labelGap
+
+	// Build the treap as a Cartesian tree over the sorted labels, as in
+	// buildIndex.
+	var stack []*orderNode[K, V]
+	for key, val := range seq {
+		t := &orderNode[K, V]{label: label, prio: rand.Uint32(), key: key, val: val}
+		var last *orderNode[K, V]
+		for len(stack) > 0 && stack[len(stack)-1].prio < t.prio {
+			last = stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+		}
+		t.left = last
+		if len(stack) > 0 {
+			stack[len(stack)-1].right = t
+		}
+		stack = append(stack, t)
+
+		m.keys, _ = m.keys.set(hamtHash(key), key, immutableEntry[V]{label, val}, 0)
+		m.len++
+		label += labelGap
+	}
+	if len(stack) > 0 {
+		m.order = stack[0]
+	}
+	return m
+}
+
+// Len returns the number of items in the dictionary.
+func (m *ImmutableOrderedDict[K, V]) Len() int {
+	return m.len
+}
+
+// Get retrieves a value by key, returns false if key doesn't exist.
+func (m *ImmutableOrderedDict[K, V]) Get(key K) (V, bool) {
+	e, ok := m.keys.get(hamtHash(key), key)
+	return e.val, ok
+}
+
+// Has checks if a key exists in the dictionary.
+func (m *ImmutableOrderedDict[K, V]) Has(key K) bool {
+	_, ok := m.keys.get(hamtHash(key), key)
+	return ok
+}
+
+// First returns the first key-value pair, returns false if the dictionary
+// is empty.
+func (m *ImmutableOrderedDict[K, V]) First() (K, V, bool) {
+	return m.order.min().entry()
+}
+
+// Last returns the last key-value pair, returns false if the dictionary is
+// empty.
+func (m *ImmutableOrderedDict[K, V]) Last() (K, V, bool) {
+	return m.order.max().entry()
+}
+
+// Set returns a version with key set to val. A new key is added at the end;
+// an existing key keeps its position.
+func (m *ImmutableOrderedDict[K, V]) Set(key K, val V) *ImmutableOrderedDict[K, V] {
+	hash := hamtHash(key)
+	if e, ok := m.keys.get(hash, key); ok {
+		keys, _ := m.keys.set(hash, key, immutableEntry[V]{e.label, val}, 0)
+		return &ImmutableOrderedDict[K, V]{
+			keys:  keys,
+			order: m.order.update(e.label, val),
+			len:   m.len,
+		}
+	}
+
+	label, ok := m.labelAfter(m.order.max())
+	if !ok {
+		return m.renumber().Set(key, val)
+	}
+	return m.insert(hash, key, val, label)
+}
+
+// Delete returns a version without key and the removed value, returns false
+// and the receiver if key doesn't exist.
+func (m *ImmutableOrderedDict[K, V]) Delete(key K) (*ImmutableOrderedDict[K, V], V, bool) {
+	hash := hamtHash(key)
+	e, ok := m.keys.get(hash, key)
+	if !ok {
+		var zero V
+		return m, zero, false
+	}
+	keys, _ := m.keys.delete(hash, key, 0)
+	return &ImmutableOrderedDict[K, V]{
+		keys:  keys,
+		order: m.order.remove(e.label),
+		len:   m.len - 1,
+	}, e.val, true
+}
+
+// MoveToEnd returns a version with key moved to the end of the order,
+// returns false and the receiver if key doesn't exist.
+func (m *ImmutableOrderedDict[K, V]) MoveToEnd(key K) (*ImmutableOrderedDict[K, V], bool) {
+	return m.move(key, func(m *ImmutableOrderedDict[K, V]) (uint64, bool) {
+		return m.labelAfter(m.order.max())
+	})
+}
+
+// MoveToStart returns a version with key moved to the start of the order,
+// returns false and the receiver if key doesn't exist.
+func (m *ImmutableOrderedDict[K, V]) MoveToStart(key K) (*ImmutableOrderedDict[K, V], bool) {
+	return m.move(key, func(m *ImmutableOrderedDict[K, V]) (uint64, bool) {
+		return m.labelAfter(nil)
+	})
+}
+
+// MoveAfter returns a version with key moved after another key, returns
+// false and the receiver if either key doesn't exist or both are the same
+// key.
+func (m *ImmutableOrderedDict[K, V]) MoveAfter(key K, after K) (*ImmutableOrderedDict[K, V], bool) {
+	if key == after || !m.Has(after) {
+		return m, false
+	}
+	return m.move(key, func(m *ImmutableOrderedDict[K, V]) (uint64, bool) {
+		e, _ := m.keys.get(hamtHash(after), after)
+		return m.labelAfter(m.order.find(e.label))
+	})
+}
+
+// MoveBefore returns a version with key moved before another key, returns
+// false and the receiver if either key doesn't exist or both are the same
+// key.
+func (m *ImmutableOrderedDict[K, V]) MoveBefore(key K, before K) (*ImmutableOrderedDict[K, V], bool) {
+	if key == before || !m.Has(before) {
+		return m, false
+	}
+	return m.move(key, func(m *ImmutableOrderedDict[K, V]) (uint64, bool) {
+		e, _ := m.keys.get(hamtHash(before), before)
+		return m.labelAfter(m.order.prev(e.label))
+	})
+}
+
+// move relabels key with the label returned by target, which is evaluated
+// once key is removed from the order. If target finds no free label the
+// entries are renumbered and the move retried.
+func (m *ImmutableOrderedDict[K, V]) move(key K, target func(m *ImmutableOrderedDict[K, V]) (uint64, bool)) (*ImmutableOrderedDict[K, V], bool) {
+	hash := hamtHash(key)
+	e, ok := m.keys.get(hash, key)
+	if !ok {
+		return m, false
+	}
+	rest := &ImmutableOrderedDict[K, V]{
+		keys:  m.keys,
+		order: m.order.remove(e.label),
+		len:   m.len - 1,
+	}
+	label, ok := target(rest)
+	if !ok {
+		return m.renumber().move(key, target)
+	}
+	return rest.insert(hash, key, e.val, label), true
+}
+
+// insert adds an entry that is not in the order under label. The key may
+// still be in the key map, in which case its label is updated.
+func (m *ImmutableOrderedDict[K, V]) insert(hash uint64, key K, val V, label uint64) *ImmutableOrderedDict[K, V] {
+	keys, _ := m.keys.set(hash, key, immutableEntry[V]{label, val}, 0)
+	t := &orderNode[K, V]{label: label, prio: rand.Uint32(), key: key, val: val}
+	return &ImmutableOrderedDict[K, V]{
+		keys:  keys,
+		order: m.order.insert(t),
+		len:   m.len + 1,
+	}
+}
+
+// insertAfter returns a version with key, which must not be present, added
+// directly after the entry with key prev, or at the start if prev is nil.
+func (m *ImmutableOrderedDict[K, V]) insertAfter(prev *K, key K, val V) *ImmutableOrderedDict[K, V] {
+	var after *orderNode[K, V]
+	if prev != nil {
+		e, _ := m.keys.get(hamtHash(*prev), *prev)
+		after = m.order.find(e.label)
+	}
+	label, ok := m.labelAfter(after)
+	if !ok {
+		return m.renumber().insertAfter(prev, key, val)
+	}
+	return m.insert(hamtHash(key), key, val, label)
+}
+
+// labelAfter returns a free label directly after prev, or before the first
+// entry if prev is nil. It returns false if there is no room.
+func (m *ImmutableOrderedDict[K, V]) labelAfter(prev *orderNode[K, V]) (uint64, bool) {
+	if m.order == nil {
+		return math.MaxUint64 / 2, true
+	}
+	if prev == nil {
+		first := m.order.min().label
+		return first - labelGap, first >= labelGap
+	}
+	next := m.order.next(prev.label)
+	if next == nil {
+		return prev.label + labelGap, prev.label <= math.MaxUint64-labelGap
+	}
+	gap := next.label - prev.label
+	return prev.label + gap/2, gap >= 2
+}
+
+// renumber returns a copy of the dictionary with evenly spaced labels.
+func (m *ImmutableOrderedDict[K, V]) renumber() *ImmutableOrderedDict[K, V] {
+	return buildImmutable(m.len, m.All())
+}
+
+// Keys returns all keys in order.
+func (m *ImmutableOrderedDict[K, V]) Keys() []K {
+	k := make([]K, 0, m.len)
+	for key := range m.KeysSeq() {
+		k = append(k, key)
+	}
+	return k
+}
+
+// Values returns all values in order.
+func (m *ImmutableOrderedDict[K, V]) Values() []V {
+	v := make([]V, 0, m.len)
+	for val := range m.ValuesSeq() {
+		v = append(v, val)
+	}
+	return v
+}
+
+// All returns an iterator over key-value pairs in order.
+func (m *ImmutableOrderedDict[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.order.forward(func(t *orderNode[K, V]) bool { return yield(t.key, t.val) })
+	}
+}
+
+// KeysSeq returns an iterator over keys in order.
+func (m *ImmutableOrderedDict[K, V]) KeysSeq() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		m.order.forward(func(t *orderNode[K, V]) bool { return yield(t.key) })
+	}
+}
+
+// ValuesSeq returns an iterator over values in order.
+func (m *ImmutableOrderedDict[K, V]) ValuesSeq() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		m.order.forward(func(t *orderNode[K, V]) bool { return yield(t.val) })
+	}
+}
+
+// Backward returns an iterator over key-value pairs in reverse order.
+func (m *ImmutableOrderedDict[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.order.backward(func(t *orderNode[K, V]) bool { return yield(t.key, t.val) })
+	}
+}
+
+// KeysBackward returns an iterator over keys in reverse order.
+func (m *ImmutableOrderedDict[K, V]) KeysBackward() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		m.order.backward(func(t *orderNode[K, V]) bool { return yield(t.key) })
+	}
+}
+
+// ValuesBackward returns an iterator over values in reverse order.
+func (m *ImmutableOrderedDict[K, V]) ValuesBackward() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		m.order.backward(func(t *orderNode[K, V]) bool { return yield(t.val) })
+	}
+}
+
+// String pretty prints the dictionary.
+func (m *ImmutableOrderedDict[K, V]) String() string {
+	var sb strings.Builder
+	sb.WriteString("ImmutableOrderedDict[")
+	first := true
+	for key, val := range m.All() {
+		if !first {
+			sb.WriteString(" ")
+		}
+		fmt.Fprintf(&sb, "%v:%v", key, val)
+		first = false
+	}
+	sb.WriteString("]")
+	return sb.String()
+}
+
+// The treap operations below never modify existing nodes; they copy the
+// nodes on the path they change and return the new root.
+
+func (t *orderNode[K, V]) clone() *orderNode[K, V] {
+	c := *t
+	return &c
+}
+
+func (t *orderNode[K, V]) entry() (K, V, bool) {
+	if t == nil {
+		var zeroK K
+		var zeroV V
+		return zeroK, zeroV, false
+	}
+	return t.key, t.val, true
+}
+
+func (t *orderNode[K, V]) min() *orderNode[K, V] {
+	for t != nil && t.left != nil {
+		t = t.left
+	}
+	return t
+}
+
+func (t *orderNode[K, V]) max() *orderNode[K, V] {
+	for t != nil && t.right != nil {
+		t = t.right
+	}
+	return t
+}
+
+// find returns the node with label, or nil.
+func (t *orderNode[K, V]) find(label uint64) *orderNode[K, V] {
+	for t != nil && t.label != label {
+		if label < t.label {
+			t = t.left
+		} else {
+			t = t.right
+		}
+	}
+	return t
+}
+
+// next returns the node with the smallest label greater than label, or nil.
+func (t *orderNode[K, V]) next(label uint64) *orderNode[K, V] {
+	var best *orderNode[K, V]
+	for t != nil {
+		if t.label > label {
+			best = t
+			t = t.left
+		} else {
+			t = t.right
+		}
+	}
+	return best
+}
+
+// prev returns the node with the largest label less than label, or nil.
+func (t *orderNode[K, V]) prev(label uint64) *orderNode[K, V] {
+	var best *orderNode[K, V]
+	for t != nil {
+		if t.label < label {
+			best = t
+			t = t.right
+		} else {
+			t = t.left
+		}
+	}
+	return best
+}
+
+// insert adds n, whose label is not in the treap.
+func (t *orderNode[K, V]) insert(n *orderNode[K, V]) *orderNode[K, V] {
+	if t == nil {
+		return n
+	}
+	if n.prio > t.prio {
+		n.left, n.right = t.split(n.label)
+		return n
+	}
+	c := t.clone()
+	if n.label < t.label {
+		c.left = t.left.insert(n)
+	} else {
+		c.right = t.right.insert(n)
+	}
+	return c
+}
+
+// split divides the treap into the nodes with labels below and above label.
+func (t *orderNode[K, V]) split(label uint64) (*orderNode[K, V], *orderNode[K, V]) {
+	if t == nil {
+		return nil, nil
+	}
+	c := t.clone()
+	if t.label < label {
+		l, r := t.right.split(label)
+		c.right = l
+		return c, r
+	}
+	l, r := t.left.split(label)
+	c.left = r
+	return l, c
+}
+
+// remove returns the treap without the node with label.
+func (t *orderNode[K, V]) remove(label uint64) *orderNode[K, V] {
+	if t == nil {
+		return nil
+	}
+	if t.label == label {
+		return merge(t.left, t.right)
+	}
+	c := t.clone()
+	if label < t.label {
+		c.left = t.left.remove(label)
+	} else {
+		c.right = t.right.remove(label)
+	}
+	return c
+}
+
+// merge joins two treaps where every label in l is below every label in r.
+func merge[K comparable, V any](l, r *orderNode[K, V]) *orderNode[K, V] {
+	if l == nil {
+		return r
+	}
+	if r == nil {
+		return l
+	}
+	if l.prio > r.prio {
+		c := l.clone()
+		c.right = merge(l.right, r)
+		return c
+	}
+	c := r.clone()
+	c.left = merge(l, r.left)
+	return c
+}
+
+// update returns the treap with the value of the node with label replaced.
+func (t *orderNode[K, V]) update(label uint64, val V) *orderNode[K, V] {
+	c := t.clone()
+	switch {
+	case label < t.label:
+		c.left = t.left.update(label, val)
+	case label > t.label:
+		c.right = t.right.update(label, val)
+	default:
+		c.val = val
+	}
+	return c
+}
+
+func (t *orderNode[K, V]) forward(yield func(*orderNode[K, V]) bool) bool {
+	if t == nil {
+		return true
+	}
+	return t.left.forward(yield) && yield(t) && t.right.forward(yield)
+}
+
+func (t *orderNode[K, V]) backward(yield func(*orderNode[K, V]) bool) bool {
+	if t == nil {
+		return true
+	}
+	return t.right.backward(yield) && yield(t) && t.left.backward(yield)
+}
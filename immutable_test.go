@@ -0,0 +1,332 @@
+package ordereddict
+
+import (
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestImmutableSet(t *testing.T) {
+	var empty ImmutableOrderedDict[string, int]
+	v1 := empty.Set("a", 1)
+	v2 := v1.Set("b", 2)
+	v3 := v2.Set("a", 10)
+
+	if empty.Len() != 0 || v1.Len() != 1 || v2.Len() != 2 || v3.Len() != 2 {
+		t.Errorf("unexpected lengths %d, %d, %d, %d", empty.Len(), v1.Len(), v2.Len(), v3.Len())
+	}
+	if val, _ := v2.Get("a"); val != 1 {
+		t.Errorf("expected v2 a=1, got %d", val)
+	}
+	if val, _ := v3.Get("a"); val != 10 {
+		t.Errorf("expected v3 a=10, got %d", val)
+	}
+	if v1.Has("b") {
+		t.Error("v1 should not see b")
+	}
+	if !slices.Equal(v3.Keys(), []string{"a", "b"}) {
+		t.Errorf("expected updated key to keep its position, got %v", v3.Keys())
+	}
+	if !slices.Equal(v3.Values(), []int{10, 2}) {
+		t.Errorf("expected values [10 2], got %v", v3.Values())
+	}
+}
+
+func TestImmutableDelete(t *testing.T) {
+	v1 := NewImmutable[string, int]().Set("a", 1).Set("b", 2).Set("c", 3)
+
+	v2, val, ok := v1.Delete("b")
+	if !ok || val != 2 {
+		t.Errorf("expected to delete b=2, got %d/%v", val, ok)
+	}
+	if !slices.Equal(v2.Keys(), []string{"a", "c"}) {
+		t.Errorf("expected [a c], got %v", v2.Keys())
+	}
+	if !slices.Equal(v1.Keys(), []string{"a", "b", "c"}) {
+		t.Errorf("expected v1 unchanged, got %v", v1.Keys())
+	}
+
+	v3, _, ok := v2.Delete("missing")
+	if ok || v3 != v2 {
+		t.Error("expected deleting a missing key to return the receiver")
+	}
+}
+
+func TestImmutableMoves(t *testing.T) {
+	v := NewImmutable[string, int]().Set("a", 1).Set("b", 2).Set("c", 3).Set("d", 4)
+
+	tests := []struct {
+		name     string
+		op       func(*ImmutableOrderedDict[string, int]) (*ImmutableOrderedDict[string, int], bool)
+		ok       bool
+		expected []string
+	}{
+		{"MoveToEnd", func(m *ImmutableOrderedDict[string, int]) (*ImmutableOrderedDict[string, int], bool) {
+			return m.MoveToEnd("a")
+		}, true, []string{"b", "c", "d", "a"}},
+		{"MoveToStart", func(m *ImmutableOrderedDict[string, int]) (*ImmutableOrderedDict[string, int], bool) {
+			return m.MoveToStart("c")
+		}, true, []string{"c", "a", "b", "d"}},
+		{"MoveAfter", func(m *ImmutableOrderedDict[string, int]) (*ImmutableOrderedDict[string, int], bool) {
+			return m.MoveAfter("a", "c")
+		}, true, []string{"b", "c", "a", "d"}},
+		{"MoveAfter last", func(m *ImmutableOrderedDict[string, int]) (*ImmutableOrderedDict[string, int], bool) {
+			return m.MoveAfter("b", "d")
+		}, true, []string{"a", "c", "d", "b"}},
+		{"MoveBefore", func(m *ImmutableOrderedDict[string, int]) (*ImmutableOrderedDict[string, int], bool) {
+			return m.MoveBefore("d", "b")
+		}, true, []string{"a", "d", "b", "c"}},
+		{"MoveBefore first", func(m *ImmutableOrderedDict[string, int]) (*ImmutableOrderedDict[string, int], bool) {
+			return m.MoveBefore("c", "a")
+		}, true, []string{"c", "a", "b", "d"}},
+		{"same key", func(m *ImmutableOrderedDict[string, int]) (*ImmutableOrderedDict[string, int], bool) {
+			return m.MoveAfter("a", "a")
+		}, false, []string{"a", "b", "c", "d"}},
+		{"missing", func(m *ImmutableOrderedDict[string, int]) (*ImmutableOrderedDict[string, int], bool) {
+			return m.MoveBefore("x", "a")
+		}, false, []string{"a", "b", "c", "d"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.op(v)
+			if ok != tt.ok {
+				t.Errorf("expected ok=%v, got %v", tt.ok, ok)
+			}
+			if !slices.Equal(got.Keys(), tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got.Keys())
+			}
+			if !slices.Equal(v.Keys(), []string{"a", "b", "c", "d"}) {
+				t.Errorf("original changed to %v", v.Keys())
+			}
+		})
+	}
+}
+
+func TestImmutableRenumber(t *testing.T) {
+	v := NewImmutable[int, int]().Set(0, 0).Set(1, 1)
+
+	// Each move halves the gap after 0, forcing a renumber well before
+	// 100 moves.
+	for i := 2; i < 100; i++ {
+		v = v.Set(i, i)
+		v, _ = v.MoveAfter(i, 0)
+	}
+
+	keys := v.Keys()
+	if len(keys) != 100 || keys[0] != 0 || keys[1] != 99 || keys[98] != 2 || keys[99] != 1 {
+		t.Errorf("unexpected order after renumbering: %v", keys)
+	}
+
+	for range 100 {
+		v, _ = v.MoveToStart(50)
+		v, _ = v.MoveToStart(51)
+	}
+	if k, _, _ := v.First(); k != 51 {
+		t.Errorf("expected 51 first, got %d", k)
+	}
+}
+
+func TestImmutableIterators(t *testing.T) {
+	v := NewImmutable[string, int]().Set("a", 1).Set("b", 2).Set("c", 3)
+
+	var keys []string
+	for k := range v.KeysSeq() {
+		keys = append(keys, k)
+	}
+	var vals []int
+	for val := range v.ValuesBackward() {
+		vals = append(vals, val)
+	}
+	var back []string
+	for k := range v.KeysBackward() {
+		back = append(back, k)
+	}
+	if !slices.Equal(keys, []string{"a", "b", "c"}) {
+		t.Errorf("KeysSeq: got %v", keys)
+	}
+	if !slices.Equal(vals, []int{3, 2, 1}) {
+		t.Errorf("ValuesBackward: got %v", vals)
+	}
+	if !slices.Equal(back, []string{"c", "b", "a"}) {
+		t.Errorf("KeysBackward: got %v", back)
+	}
+
+	count := 0
+	for range v.All() {
+		count++
+		break
+	}
+	if count != 1 {
+		t.Errorf("expected early exit after 1 iteration, got %d", count)
+	}
+
+	if k, v, ok := v.Last(); !ok || k != "c" || v != 3 {
+		t.Errorf("Last: expected c:3, got %s:%d", k, v)
+	}
+	if v.String() != "ImmutableOrderedDict[a:1 b:2 c:3]" {
+		t.Errorf("unexpected String %q", v.String())
+	}
+	if _, _, ok := NewImmutable[string, int]().First(); ok {
+		t.Error("expected First on empty dict to fail")
+	}
+}
+
+func TestImmutableConversion(t *testing.T) {
+	od := New[string, int]()
+	od.Set("b", 2)
+	od.Set("a", 1)
+
+	im := od.Immutable()
+	od.Set("c", 3)
+	if im.Has("c") {
+		t.Error("immutable copy should not see later writes")
+	}
+
+	back := im.Set("d", 4).Mutable()
+	if !slices.Equal(back.Keys(), []string{"b", "a", "d"}) {
+		t.Errorf("expected [b a d], got %v", back.Keys())
+	}
+	checkList(t, back)
+}
+
+func TestHAMTCollisions(t *testing.T) {
+	// Force full-hash collisions and partial ones by choosing hashes.
+	var h *hamtNode[string, int]
+	h, _ = h.set(1, "a", 1, 0)
+	h, _ = h.set(1, "b", 2, 0)
+	h, _ = h.set(1|1<<40, "c", 3, 0)
+	h2, added := h.set(1, "b", 20, 0)
+	if added {
+		t.Error("expected update of colliding key not to add")
+	}
+
+	for _, tt := range []struct {
+		hash uint64
+		key  string
+		val  int
+	}{{1, "a", 1}, {1, "b", 20}, {1 | 1<<40, "c", 3}} {
+		if val, ok := h2.get(tt.hash, tt.key); !ok || val != tt.val {
+			t.Errorf("get(%s): expected %d, got %d", tt.key, tt.val, val)
+		}
+	}
+	if val, _ := h.get(1, "b"); val != 2 {
+		t.Error("previous version should be unchanged")
+	}
+
+	h3, removed := h2.delete(1, "a", 0)
+	if !removed {
+		t.Fatal("expected a to be removed")
+	}
+	if _, ok := h3.get(1, "a"); ok {
+		t.Error("a should be gone")
+	}
+	if _, ok := h3.get(1, "b"); !ok {
+		t.Error("b should survive removal of a colliding key")
+	}
+	h3, _ = h3.delete(1, "b", 0)
+	h3, _ = h3.delete(1|1<<40, "c", 0)
+	if h3 != nil {
+		t.Error("expected empty trie to be nil")
+	}
+}
+
+func TestImmutableRandomized(t *testing.T) {
+	r := rand.New(rand.NewPCG(5, 6))
+	od := New[int, int]()
+	im := NewImmutable[int, int]()
+	var versions []*ImmutableOrderedDict[int, int]
+	var expected [][]int
+
+	for i := range 3000 {
+		key := r.IntN(300)
+		switch r.IntN(6) {
+		case 0, 1:
+			od.Set(key, i)
+			im = im.Set(key, i)
+		case 2:
+			od.Delete(key)
+			im, _, _ = im.Delete(key)
+		case 3:
+			od.MoveToStart(key)
+			im, _ = im.MoveToStart(key)
+		case 4:
+			other := r.IntN(300)
+			od.MoveAfter(key, other)
+			im, _ = im.MoveAfter(key, other)
+		case 5:
+			other := r.IntN(300)
+			od.MoveBefore(key, other)
+			im, _ = im.MoveBefore(key, other)
+		}
+		if i%100 == 0 {
+			versions = append(versions, im)
+			expected = append(expected, od.Keys())
+		}
+	}
+
+	if !slices.Equal(im.Keys(), od.Keys()) || !slices.Equal(im.Values(), od.Values()) {
+		t.Fatal("immutable dict diverged from OrderedDict")
+	}
+	for key := range 300 {
+		v1, ok1 := od.Get(key)
+		v2, ok2 := im.Get(key)
+		if v1 != v2 || ok1 != ok2 {
+			t.Fatalf("Get(%d): expected %d/%v, got %d/%v", key, v1, ok1, v2, ok2)
+		}
+	}
+	for i, v := range versions {
+		if !slices.Equal(v.Keys(), expected[i]) {
+			t.Fatalf("version %d changed after later updates", i)
+		}
+	}
+}
+
+func TestImmutableConcurrentReads(t *testing.T) {
+	im := NewImmutable[int, int]()
+	for i := range 1000 {
+		im = im.Set(i, i)
+	}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			local := im
+			for i := range 1000 {
+				if val, ok := im.Get(i); !ok || val != i {
+					t.Errorf("Get(%d): got %d", i, val)
+					return
+				}
+				local, _ = local.MoveToStart(i)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkImmutableSet(b *testing.B) {
+	im := NewImmutable[int, int]()
+	for i := range 100000 {
+		im = im.Set(i, i)
+	}
+
+	b.ResetTimer()
+	for i := range b.N {
+		im.Set(i%100000, i)
+	}
+}
+
+func BenchmarkImmutableGet(b *testing.B) {
+	im := NewImmutable[int, int]()
+	for i := range 100000 {
+		im = im.Set(i, i)
+	}
+
+	b.ResetTimer()
+	for i := range b.N {
+		im.Get(i % 100000)
+	}
+}
@@ -0,0 +1,298 @@
+package ordereddict
+
+import "math/rand/v2"
+
+// The position index is a treap keyed implicitly by list position. Each
+// tree node records the size of its subtree, so the position of a node and
+// the node at a position can both be found in O(log n). The index is only
+// built once a positional query is made; until then linking and unlinking
+// stay O(1).
+
+// posNode is a node of the position index.
+type posNode[K comparable, V any] struct {
+	n      *node[K, V]
+	left   *posNode[K, V]
+	right  *posNode[K, V]
+	parent *posNode[K, V]
+	prio   uint32
+	size   int
+}
+
+func (t *posNode[K, V]) sizeOf() int {
+	if t == nil {
+		return 0
+	}
+	return t.size
+}
+
+func (t *posNode[K, V]) update() {
+	t.size = 1 + t.left.sizeOf() + t.right.sizeOf()
+}
+
+// At returns the entry at position i in the order, returns false if i is
+// out of range.
+func (o *OrderedDict[K, V]) At(i int) (K, V, bool) {
+	excl := o.lockIndex()
+	defer o.runlock(excl)
+	n := o.nodeAt(i)
+	if n == nil {
+		var zeroK K
+		var zeroV V
+		return zeroK, zeroV, false
+	}
+	return n.key, n.val, true
+}
+
+// IndexOf returns the position of a key in the order, returns false if key
+// doesn't exist.
+func (o *OrderedDict[K, V]) IndexOf(key K) (int, bool) {
+	excl := o.lockIndex()
+	defer o.runlock(excl)
+	n, ok := o.s.data[key]
+	if !ok {
+		return -1, false
+	}
+	return o.position(n), true
+}
+
+// Slice returns a new OrderedDict holding the entries at positions
+// [start, end) in order. Both bounds are clamped to [0, Len()]. The
+// returned dictionary is independent of o and has no LRU or expiry
+// settings.
+func (o *OrderedDict[K, V]) Slice(start, end int) *OrderedDict[K, V] {
+	excl := o.lockIndex()
+	defer o.runlock(excl)
+	start = min(max(start, 0), o.s.len)
+	end = min(max(end, start), o.s.len)
+
+	out := NewWithCapacity[K, V](end - start)
+	if start == end {
+		return out
+	}
+	curr := o.nodeAt(start)
+	for range end - start {
+		n := &node[K, V]{key: curr.key, val: curr.val}
+		out.linkToEnd(n)
+		out.s.data[n.key] = n
+		out.s.len++
+		curr = curr.next
+	}
+	return out
+}
+
+// lockIndex acquires the lock for a positional query, building the position
+// index first if needed, and reports whether the write lock was taken.
+func (o *OrderedDict[K, V]) lockIndex() bool {
+	excl := o.rlock()
+	if o.s.indexed {
+		return excl
+	}
+	if !excl {
+		o.runlock(false)
+		o.lock()
+		o.expire()
+	}
+	if !o.s.indexed {
+		o.buildIndex()
+	}
+	return true
+}
+
+// buildIndex builds the position index from the current order in O(n).
+// It must be called with the write lock held.
+func (o *OrderedDict[K, V]) buildIndex() {
+	// Build a Cartesian tree over the list using random priorities, which
+	// gives the same shape as inserting the nodes into a treap one by one.
+	var stack []*posNode[K, V]
+	for curr := o.s.head.next; curr != o.s.tail; curr = curr.next {
+		t := &posNode[K, V]{n: curr, prio: rand.Uint32(), size: 1}
+		curr.pos = t
+		var last *posNode[K, V]
+		for len(stack) > 0 && stack[len(stack)-1].prio < t.prio {
+			last = stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+		}
+		t.left = last
+		if last != nil {
+			last.parent = t
+		}
+		if len(stack) > 0 {
+			top := stack[len(stack)-1]
+			top.right = t
+			t.parent = top
+		}
+		stack = append(stack, t)
+	}
+
+	o.s.root = nil
+	if len(stack) > 0 {
+		o.s.root = stack[0]
+		updateSizes(o.s.root)
+	}
+	o.s.indexed = true
+}
+
+// updateSizes recomputes the subtree sizes below t.
+func updateSizes[K comparable, V any](t *posNode[K, V]) {
+	if t == nil {
+		return
+	}
+	updateSizes(t.left)
+	updateSizes(t.right)
+	t.update()
+}
+
+// dropIndex discards the position index. It is rebuilt by the next
+// positional query. Callers that relink many nodes at once use this instead
+// of maintaining the index node by node.
+func (o *OrderedDict[K, V]) dropIndex() {
+	if !o.s.indexed {
+		return
+	}
+	for curr := o.s.head.next; curr != o.s.tail; curr = curr.next {
+		curr.pos = nil
+	}
+	o.s.indexed = false
+	o.s.root = nil
+}
+
+// index adds a newly linked node to the position index, directly after
+// prev, which is either another indexed node or the head sentinel.
+func (o *OrderedDict[K, V]) index(n, prev *node[K, V]) {
+	if !o.s.indexed {
+		return
+	}
+	t := &posNode[K, V]{n: n, prio: rand.Uint32(), size: 1}
+	n.pos = t
+
+	// Attach t as the in-order successor of prev: the leftmost slot of
+	// prev's right subtree, or prev's right child if it has none.
+	var parent *posNode[K, V]
+	left := true
+	switch {
+	case prev == o.s.head && o.s.root != nil:
+		parent = o.s.root
+		for parent.left != nil {
+			parent = parent.left
+		}
+	case prev != o.s.head && prev.pos.right == nil:
+		parent = prev.pos
+		left = false
+	case prev != o.s.head:
+		parent = prev.pos.right
+		for parent.left != nil {
+			parent = parent.left
+		}
+	}
+
+	t.parent = parent
+	switch {
+	case parent == nil:
+		o.s.root = t
+	case left:
+		parent.left = t
+	default:
+		parent.right = t
+	}
+	for p := parent; p != nil; p = p.parent {
+		p.size++
+	}
+
+	for t.parent != nil && t.prio > t.parent.prio {
+		o.rotateUp(t)
+	}
+}
+
+// unindex removes a node from the position index.
+func (o *OrderedDict[K, V]) unindex(n *node[K, V]) {
+	t := n.pos
+	if t == nil {
+		return
+	}
+	n.pos = nil
+
+	// Rotate t down until it is a leaf, then detach it.
+	for t.left != nil || t.right != nil {
+		if t.right == nil || (t.left != nil && t.left.prio > t.right.prio) {
+			o.rotateUp(t.left)
+		} else {
+			o.rotateUp(t.right)
+		}
+	}
+
+	parent := t.parent
+	switch {
+	case parent == nil:
+		o.s.root = nil
+	case parent.left == t:
+		parent.left = nil
+	default:
+		parent.right = nil
+	}
+	for p := parent; p != nil; p = p.parent {
+		p.size--
+	}
+}
+
+// rotateUp rotates t above its parent, preserving the in-order sequence.
+func (o *OrderedDict[K, V]) rotateUp(t *posNode[K, V]) {
+	p := t.parent
+	g := p.parent
+	if p.left == t {
+		p.left = t.right
+		if t.right != nil {
+			t.right.parent = p
+		}
+		t.right = p
+	} else {
+		p.right = t.left
+		if t.left != nil {
+			t.left.parent = p
+		}
+		t.left = p
+	}
+	p.parent = t
+	t.parent = g
+	switch {
+	case g == nil:
+		o.s.root = t
+	case g.left == p:
+		g.left = t
+	default:
+		g.right = t
+	}
+	p.update()
+	t.update()
+}
+
+// position returns the position of an indexed node.
+func (o *OrderedDict[K, V]) position(n *node[K, V]) int {
+	t := n.pos
+	i := t.left.sizeOf()
+	for ; t.parent != nil; t = t.parent {
+		if t.parent.right == t {
+			i += t.parent.left.sizeOf() + 1
+		}
+	}
+	return i
+}
+
+// nodeAt returns the node at position i, or nil if i is out of range.
+func (o *OrderedDict[K, V]) nodeAt(i int) *node[K, V] {
+	if i < 0 || i >= o.s.len {
+		return nil
+	}
+	t := o.s.root
+	for {
+		ls := t.left.sizeOf()
+		switch {
+		case i < ls:
+			t = t.left
+		case i == ls:
+			return t.n
+		default:
+			i -= ls + 1
+			t = t.right
+		}
+	}
+}
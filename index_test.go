@@ -0,0 +1,292 @@
+package ordereddict
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+// checkIndex verifies the position index against the linked list: every
+// node is indexed, subtree sizes and parent pointers are consistent, the
+// heap property holds and positions match list order.
+func checkIndex[K comparable, V any](t *testing.T, od *OrderedDict[K, V]) {
+	t.Helper()
+	if !od.s.indexed {
+		return
+	}
+	if od.s.root.sizeOf() != od.s.len {
+		t.Fatalf("index size %d does not match len %d", od.s.root.sizeOf(), od.s.len)
+	}
+	if od.s.root != nil && od.s.root.parent != nil {
+		t.Fatal("index root has a parent")
+	}
+
+	var walk func(p *posNode[K, V]) int
+	walk = func(p *posNode[K, V]) int {
+		if p == nil {
+			return 0
+		}
+		for _, c := range []*posNode[K, V]{p.left, p.right} {
+			if c == nil {
+				continue
+			}
+			if c.parent != p {
+				t.Fatalf("broken parent pointer below %v", p.n.key)
+			}
+			if c.prio > p.prio {
+				t.Fatalf("heap property violated below %v", p.n.key)
+			}
+		}
+		size := 1 + walk(p.left) + walk(p.right)
+		if p.size != size {
+			t.Fatalf("node %v: size %d, expected %d", p.n.key, p.size, size)
+		}
+		return size
+	}
+	walk(od.s.root)
+
+	i := 0
+	for curr := od.s.head.next; curr != od.s.tail; curr = curr.next {
+		if curr.pos == nil || curr.pos.n != curr {
+			t.Fatalf("node %v is not indexed", curr.key)
+		}
+		if pos := od.position(curr); pos != i {
+			t.Fatalf("node %v: position %d, expected %d", curr.key, pos, i)
+		}
+		if od.nodeAt(i) != curr {
+			t.Fatalf("nodeAt(%d) does not return %v", i, curr.key)
+		}
+		i++
+	}
+}
+
+func TestAt(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
+
+	for i, expected := range []string{"a", "b", "c"} {
+		key, val, ok := od.At(i)
+		if !ok {
+			t.Fatalf("expected At(%d) to succeed", i)
+		}
+		if key != expected || val != i+1 {
+			t.Errorf("At(%d): expected %s:%d, got %s:%d", i, expected, i+1, key, val)
+		}
+	}
+
+	if _, _, ok := od.At(3); ok {
+		t.Error("expected At(3) to fail")
+	}
+	if _, _, ok := od.At(-1); ok {
+		t.Error("expected At(-1) to fail")
+	}
+}
+
+func TestAtEmpty(t *testing.T) {
+	od := New[string, int]()
+	if _, _, ok := od.At(0); ok {
+		t.Error("expected At(0) on empty dict to fail")
+	}
+}
+
+func TestIndexOf(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
+
+	for i, key := range []string{"a", "b", "c"} {
+		pos, ok := od.IndexOf(key)
+		if !ok || pos != i {
+			t.Errorf("IndexOf(%s): expected %d, got %d", key, i, pos)
+		}
+	}
+
+	if pos, ok := od.IndexOf("missing"); ok || pos != -1 {
+		t.Errorf("expected IndexOf(missing) to fail with -1, got %d", pos)
+	}
+}
+
+func TestIndexMaintainedAfterBuild(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
+
+	od.At(0) // build the index
+
+	od.Set("d", 4)
+	od.MoveToStart("c")
+	od.MoveAfter("a", "d")
+	od.Delete("b")
+	checkIndex(t, od)
+
+	// Order is now c, d, a.
+	expected := []string{"c", "d", "a"}
+	for i, key := range expected {
+		if pos, _ := od.IndexOf(key); pos != i {
+			t.Errorf("IndexOf(%s): expected %d, got %d", key, i, pos)
+		}
+		if k, _, _ := od.At(i); k != key {
+			t.Errorf("At(%d): expected %s, got %s", i, key, k)
+		}
+	}
+
+	od.Clear()
+	checkIndex(t, od)
+	od.Set("x", 1)
+	checkIndex(t, od)
+	if k, _, ok := od.At(0); !ok || k != "x" {
+		t.Errorf("expected x at 0 after clear, got %s", k)
+	}
+}
+
+func TestIndexRandomized(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	od := New[int, int]()
+	od.At(0)
+
+	for range 5000 {
+		key := r.IntN(200)
+		switch r.IntN(12) {
+		case 0, 1:
+			od.Set(key, key)
+		case 2:
+			od.Delete(key)
+		case 3:
+			od.MoveToEnd(key)
+		case 4:
+			od.MoveToStart(key)
+		case 5:
+			if other := r.IntN(200); other != key {
+				od.MoveAfter(key, other)
+			}
+		case 6:
+			od.SetAfter(r.IntN(200), key, key)
+		case 7:
+			od.SetBefore(r.IntN(200), key, key)
+		case 8:
+			od.SetFirst(key, key)
+		case 9:
+			od.MoveBefore(key, r.IntN(200))
+		case 10:
+			od.Swap(key, r.IntN(200))
+		case 11:
+			od.Rotate(r.IntN(20) - 10)
+		}
+	}
+	checkList(t, od)
+
+	keys := od.Keys()
+	for i, key := range keys {
+		if pos, ok := od.IndexOf(key); !ok || pos != i {
+			t.Fatalf("IndexOf(%d): expected %d, got %d", key, i, pos)
+		}
+		if k, _, _ := od.At(i); k != key {
+			t.Fatalf("At(%d): expected %d, got %d", i, key, k)
+		}
+	}
+}
+
+func TestIndexWithLRUAndTTL(t *testing.T) {
+	clock := newFakeClock()
+	od := NewLRU[int, int](5, nil, WithClock(clock.Now))
+	od.At(0)
+
+	for i := range 10 {
+		od.SetWithTTL(i, i, time.Duration(i+1)*time.Second)
+	}
+	od.Get(6)
+	checkIndex(t, od)
+
+	// Order is 5, 7, 8, 9, 6. Advancing 7s expires 5 and 6.
+	clock.Advance(7 * time.Second)
+	if pos, ok := od.IndexOf(8); !ok || pos != 1 {
+		t.Errorf("IndexOf(8): expected 1, got %d", pos)
+	}
+	checkIndex(t, od)
+	if od.Len() != 3 {
+		t.Errorf("expected len=3, got %d", od.Len())
+	}
+}
+
+func TestSlice(t *testing.T) {
+	od := New[string, int]()
+	for i, key := range []string{"a", "b", "c", "d", "e"} {
+		od.Set(key, i)
+	}
+
+	tests := []struct {
+		name       string
+		start, end int
+		expected   []string
+	}{
+		{"middle", 1, 4, []string{"b", "c", "d"}},
+		{"prefix", 0, 2, []string{"a", "b"}},
+		{"suffix", 3, 5, []string{"d", "e"}},
+		{"all", 0, 5, []string{"a", "b", "c", "d", "e"}},
+		{"empty", 2, 2, nil},
+		{"reversed bounds", 4, 1, nil},
+		{"clamped", -3, 100, []string{"a", "b", "c", "d", "e"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := od.Slice(tt.start, tt.end)
+			keys := s.Keys()
+			if len(keys) != len(tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, keys)
+			}
+			for i, key := range keys {
+				if key != tt.expected[i] {
+					t.Errorf("position %d: expected %s, got %s", i, tt.expected[i], key)
+				}
+			}
+		})
+	}
+}
+
+func TestSliceIndependent(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+
+	s := od.Slice(0, 2)
+	s.Set("a", 100)
+	s.Set("c", 3)
+
+	if val, _ := od.Get("a"); val != 1 {
+		t.Error("modifying the slice should not affect the original")
+	}
+	if od.Has("c") {
+		t.Error("adding to the slice should not affect the original")
+	}
+}
+
+func BenchmarkAt(b *testing.B) {
+	od := New[int, int]()
+	for i := range 100000 {
+		od.Set(i, i)
+	}
+	od.At(0)
+
+	b.ResetTimer()
+	for i := range b.N {
+		od.At(i % 100000)
+	}
+}
+
+func BenchmarkIndexOf(b *testing.B) {
+	od := New[int, int]()
+	for i := range 100000 {
+		od.Set(i, i)
+	}
+	od.IndexOf(0)
+
+	b.ResetTimer()
+	for i := range b.N {
+		od.IndexOf(i % 100000)
+	}
+}
@@ -0,0 +1,188 @@
+package ordereddict
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"reflect"
+	"strconv"
+)
+
+// MarshalJSON encodes the dictionary as a JSON object whose members appear
+// in insertion order. Keys are encoded the same way encoding/json encodes
+// map keys: string kinds are used directly, otherwise encoding.TextMarshaler
+// is used, and integer kinds are formatted in base 10.
+func (o *OrderedDict[K, V]) MarshalJSON() ([]byte, error) {
+	if o == nil {
+		return []byte("null"), nil
+	}
+
+	excl := o.rlock()
+	defer o.runlock(excl)
+	return marshalObject(o.s.all())
+}
+
+// marshalObject encodes the entries produced by all as a JSON object.
+func marshalObject[K comparable, V any](all iter.Seq2[K, V]) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	first := true
+	for key, val := range all {
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		name, err := marshalKey(key)
+		if err != nil {
+			return nil, err
+		}
+		kb, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(kb)
+		buf.WriteByte(':')
+		vb, err := json.Marshal(val)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(vb)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON decodes a JSON object into the dictionary, replacing its
+// contents. Members are inserted in document order; when a key appears more
+// than once the last value wins and the key keeps its first position.
+// Keys are decoded the same way encoding/json decodes map keys. A JSON null
+// leaves the dictionary unchanged.
+func (o *OrderedDict[K, V]) UnmarshalJSON(data []byte) error {
+	// Decode into a fresh dictionary so o is left untouched on error.
+	tmp, err := unmarshalObject[K, V](data, reflect.TypeOf(o))
+	if tmp == nil {
+		return err
+	}
+
+	o.lock()
+	defer o.unlock()
+	o.replace(tmp.s)
+	return nil
+}
+
+// unmarshalObject decodes a JSON object into a new OrderedDict, for the
+// UnmarshalJSON method of a value of type typ. It returns nil if data is a
+// JSON null or on error.
+func unmarshalObject[K comparable, V any](data []byte, typ reflect.Type) (*OrderedDict[K, V], error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok == nil {
+		return nil, nil
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, &json.UnmarshalTypeError{
+			Value: jsonKind(tok),
+			Type:  typ,
+		}
+	}
+
+	out := New[K, V]()
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		name, ok := tok.(string)
+		if !ok {
+			return nil, fmt.Errorf("ordereddict: expected object key, got %v", tok)
+		}
+		key, err := unmarshalKey[K](name)
+		if err != nil {
+			return nil, err
+		}
+		var val V
+		if err := dec.Decode(&val); err != nil {
+			return nil, err
+		}
+		out.Set(key, val)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// marshalKey converts a key to a JSON object member name.
+func marshalKey[K comparable](key K) (string, error) {
+	rv := reflect.ValueOf(&key).Elem()
+	if rv.Kind() == reflect.String {
+		return rv.String(), nil
+	}
+	if tm, ok := any(key).(encoding.TextMarshaler); ok {
+		if rv.Kind() == reflect.Pointer && rv.IsNil() {
+			return "", nil
+		}
+		b, err := tm.MarshalText()
+		return string(b), err
+	}
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	}
+	return "", &json.UnsupportedTypeError{Type: rv.Type()}
+}
+
+// unmarshalKey converts a JSON object member name to a key.
+func unmarshalKey[K comparable](name string) (K, error) {
+	var key K
+	if tu, ok := any(&key).(encoding.TextUnmarshaler); ok {
+		err := tu.UnmarshalText([]byte(name))
+		return key, err
+	}
+	rv := reflect.ValueOf(&key).Elem()
+	switch rv.Kind() {
+	case reflect.String:
+		rv.SetString(name)
+		return key, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(name, 10, 64)
+		if err != nil || rv.OverflowInt(n) {
+			return key, &json.UnmarshalTypeError{Value: "number " + name, Type: rv.Type()}
+		}
+		rv.SetInt(n)
+		return key, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(name, 10, 64)
+		if err != nil || rv.OverflowUint(n) {
+			return key, &json.UnmarshalTypeError{Value: "number " + name, Type: rv.Type()}
+		}
+		rv.SetUint(n)
+		return key, nil
+	}
+	return key, &json.UnsupportedTypeError{Type: rv.Type()}
+}
+
+// jsonKind describes a top-level JSON token for error messages.
+func jsonKind(tok json.Token) string {
+	switch v := tok.(type) {
+	case json.Delim:
+		if v == '[' {
+			return "array"
+		}
+		return "object"
+	case bool:
+		return "bool"
+	case float64, json.Number:
+		return "number"
+	case string:
+		return "string"
+	}
+	return "value"
+}
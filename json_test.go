@@ -0,0 +1,270 @@
+package ordereddict
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	od := New[string, int]()
+	od.Set("z", 1)
+	od.Set("a", 2)
+	od.Set("m", 3)
+
+	b, err := json.Marshal(od)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{"z":1,"a":2,"m":3}`
+	if string(b) != expected {
+		t.Errorf("expected %s, got %s", expected, b)
+	}
+}
+
+func TestMarshalJSONEmpty(t *testing.T) {
+	od := New[string, int]()
+
+	b, err := json.Marshal(od)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(b) != "{}" {
+		t.Errorf("expected {}, got %s", b)
+	}
+}
+
+func TestMarshalJSONNested(t *testing.T) {
+	inner := New[string, bool]()
+	inner.Set("y", true)
+	inner.Set("x", false)
+
+	od := New[string, any]()
+	od.Set("inner", inner)
+	od.Set("list", []int{1, 2})
+	od.Set("quote", `"<>"`)
+
+	b, err := json.Marshal(od)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{"inner":{"y":true,"x":false},"list":[1,2],"quote":"\"\u003c\u003e\""}`
+	if string(b) != expected {
+		t.Errorf("expected %s, got %s", expected, b)
+	}
+}
+
+func TestMarshalJSONIntKeys(t *testing.T) {
+	od := New[int, string]()
+	od.Set(10, "ten")
+	od.Set(-1, "minus one")
+
+	b, err := json.Marshal(od)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{"10":"ten","-1":"minus one"}`
+	if string(b) != expected {
+		t.Errorf("expected %s, got %s", expected, b)
+	}
+}
+
+type textKey struct {
+	a, b string
+}
+
+func (k textKey) MarshalText() ([]byte, error) {
+	return []byte(k.a + "/" + k.b), nil
+}
+
+func (k *textKey) UnmarshalText(text []byte) error {
+	a, b, ok := strings.Cut(string(text), "/")
+	if !ok {
+		return fmt.Errorf("invalid key %q", text)
+	}
+	k.a, k.b = a, b
+	return nil
+}
+
+func TestJSONTextMarshalerKeys(t *testing.T) {
+	od := New[textKey, int]()
+	od.Set(textKey{"b", "2"}, 2)
+	od.Set(textKey{"a", "1"}, 1)
+
+	b, err := json.Marshal(od)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{"b/2":2,"a/1":1}`
+	if string(b) != expected {
+		t.Fatalf("expected %s, got %s", expected, b)
+	}
+
+	decoded := New[textKey, int]()
+	if err := json.Unmarshal(b, decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	keys := decoded.Keys()
+	expectedKeys := []textKey{{"b", "2"}, {"a", "1"}}
+	if len(keys) != len(expectedKeys) {
+		t.Fatalf("expected %d keys, got %d", len(expectedKeys), len(keys))
+	}
+	for i, key := range keys {
+		if key != expectedKeys[i] {
+			t.Errorf("position %d: expected %v, got %v", i, expectedKeys[i], key)
+		}
+	}
+
+	if err := json.Unmarshal([]byte(`{"nokey":1}`), decoded); err == nil {
+		t.Error("expected error for invalid text key")
+	}
+}
+
+func TestMarshalJSONUnsupportedKey(t *testing.T) {
+	od := New[float64, int]()
+	od.Set(1.5, 1)
+
+	_, err := json.Marshal(od)
+	var typeErr *json.UnsupportedTypeError
+	if !errors.As(err, &typeErr) {
+		t.Errorf("expected UnsupportedTypeError, got %v", err)
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	od := New[string, int]()
+	err := json.Unmarshal([]byte(`{"z": 1, "a": 2, "m": 3}`), od)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	keys := od.Keys()
+	expected := []string{"z", "a", "m"}
+	if len(keys) != len(expected) {
+		t.Fatalf("expected %d keys, got %d", len(expected), len(keys))
+	}
+	for i, key := range keys {
+		if key != expected[i] {
+			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
+		}
+	}
+
+	val, ok := od.Get("a")
+	if !ok || val != 2 {
+		t.Errorf("expected a=2, got %d", val)
+	}
+}
+
+func TestUnmarshalJSONReplacesContents(t *testing.T) {
+	od := New[string, int]()
+	od.Set("old", 1)
+
+	if err := json.Unmarshal([]byte(`{"new": 2}`), od); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if od.Has("old") {
+		t.Error("existing keys should be replaced")
+	}
+	if od.Len() != 1 {
+		t.Errorf("expected len=1, got %d", od.Len())
+	}
+}
+
+func TestUnmarshalJSONDuplicateKeys(t *testing.T) {
+	od := New[string, int]()
+	if err := json.Unmarshal([]byte(`{"a": 1, "b": 2, "a": 3}`), od); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	keys := od.Keys()
+	expected := []string{"a", "b"}
+	for i, key := range keys {
+		if key != expected[i] {
+			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
+		}
+	}
+	if val, _ := od.Get("a"); val != 3 {
+		t.Errorf("expected last value 3, got %d", val)
+	}
+}
+
+func TestUnmarshalJSONIntKeys(t *testing.T) {
+	od := New[int8, string]()
+	if err := json.Unmarshal([]byte(`{"3": "c", "-1": "a"}`), od); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	keys := od.Keys()
+	if len(keys) != 2 || keys[0] != 3 || keys[1] != -1 {
+		t.Errorf("expected [3 -1], got %v", keys)
+	}
+
+	err := json.Unmarshal([]byte(`{"300": "overflow"}`), od)
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) {
+		t.Errorf("expected UnmarshalTypeError, got %v", err)
+	}
+}
+
+func TestUnmarshalJSONErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"array", `[1, 2]`},
+		{"string", `"a"`},
+		{"bad value", `{"a": "not a number"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			od := New[string, int]()
+			od.Set("keep", 1)
+			if err := json.Unmarshal([]byte(tt.input), od); err == nil {
+				t.Error("expected error")
+			}
+			if val, ok := od.Get("keep"); !ok || val != 1 {
+				t.Error("dict should be unchanged after a failed decode")
+			}
+		})
+	}
+}
+
+func TestUnmarshalJSONNull(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	if err := json.Unmarshal([]byte(`null`), od); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if od.Len() != 1 {
+		t.Errorf("expected null to leave dict unchanged, got len=%d", od.Len())
+	}
+}
+
+func TestJSONStructField(t *testing.T) {
+	type config struct {
+		Name    string                       `json:"name"`
+		Headers *OrderedDict[string, string] `json:"headers"`
+	}
+
+	input := `{"name":"svc","headers":{"X-B":"2","X-A":"1"}}`
+	var cfg config
+	if err := json.Unmarshal([]byte(input), &cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Headers == nil {
+		t.Fatal("headers not decoded")
+	}
+
+	cfg.Headers.Set("X-C", "3")
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{"name":"svc","headers":{"X-B":"2","X-A":"1","X-C":"3"}}`
+	if string(b) != expected {
+		t.Errorf("expected %s, got %s", expected, b)
+	}
+}
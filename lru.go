@@ -0,0 +1,89 @@
+package ordereddict
+
+// Stats holds the cache counters of an LRU dictionary.
+type Stats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+}
+
+// NewLRU creates an OrderedDict that behaves as a least-recently-used cache
+// holding at most maxLen entries. Get and Set mark a key as most recently
+// used by moving it to the end; when an insert makes the dictionary exceed
+// maxLen, entries are evicted from the start. onEvict, if non-nil, is called
+// for every evicted entry while the dictionary is locked, so it must not call
+// back into the dictionary. NewLRU panics if maxLen is not positive.
+func NewLRU[K comparable, V any](maxLen int, onEvict func(key K, val V), opts ...Option) *OrderedDict[K, V] {
+	if maxLen <= 0 {
+		panic("ordereddict: NewLRU requires a positive maxLen")
+	}
+	o := NewWithCapacity[K, V](maxLen, opts...)
+	o.maxLen = maxLen
+	o.onEvict = onEvict
+	return o
+}
+
+// Peek retrieves a value by key without marking it as recently used or
+// updating the cache counters.
+func (o *OrderedDict[K, V]) Peek(key K) (V, bool) {
+	excl := o.rlock()
+	defer o.runlock(excl)
+	node, ok := o.s.data[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	return node.val, true
+}
+
+// Stats returns the hit, miss and eviction counters. Hits and misses are
+// only counted for dictionaries created with NewLRU.
+func (o *OrderedDict[K, V]) Stats() Stats {
+	excl := o.rlock()
+	defer o.runlock(excl)
+	return o.stats
+}
+
+// getLRU looks up a key and promotes it to the most recently used position.
+func (o *OrderedDict[K, V]) getLRU(key K) (V, bool) {
+	o.lock()
+	defer o.unlock()
+	o.expire()
+	node, ok := o.s.data[key]
+	if !ok {
+		o.stats.Misses++
+		var zero V
+		return zero, false
+	}
+	o.stats.Hits++
+	o.unlinkNode(node)
+	o.linkToEnd(node)
+	return node.val, true
+}
+
+// evict removes the least recently used entries until the dictionary is
+// within maxLen. It must be called with the write lock held. Eviction is
+// deferred while a transaction is open and applied when it commits.
+func (o *OrderedDict[K, V]) evict() {
+	o.evictExcept(nil)
+}
+
+// evictExcept is like evict but never removes keep, so that an entry placed
+// at the start by SetFirst or SetBefore is not evicted as soon as it is
+// added.
+func (o *OrderedDict[K, V]) evictExcept(keep *node[K, V]) {
+	if o.inTx {
+		return
+	}
+	for o.maxLen > 0 && o.s.len > o.maxLen {
+		n := o.s.head.next
+		if n == keep {
+			n = n.next
+		}
+		o.removeNode(n)
+		o.stats.Evictions++
+		if o.onEvict != nil {
+			o.onEvict(n.key, n.val)
+		}
+	}
+}
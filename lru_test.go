@@ -0,0 +1,243 @@
+package ordereddict
+
+import (
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestNewLRU(t *testing.T) {
+	od := NewLRU[string, int](2, nil)
+
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
+
+	if od.Len() != 2 {
+		t.Errorf("expected len=2, got %d", od.Len())
+	}
+	if od.Has("a") {
+		t.Error("oldest key should have been evicted")
+	}
+
+	keys := od.Keys()
+	expected := []string{"b", "c"}
+	for i, key := range keys {
+		if key != expected[i] {
+			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
+		}
+	}
+}
+
+func TestNewLRUInvalidMaxLen(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for non-positive maxLen")
+		}
+	}()
+	NewLRU[string, int](0, nil)
+}
+
+func TestLRUGetPromotes(t *testing.T) {
+	od := NewLRU[string, int](3, nil)
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
+
+	if val, ok := od.Get("a"); !ok || val != 1 {
+		t.Fatalf("expected a=1, got %d", val)
+	}
+
+	keys := od.Keys()
+	expected := []string{"b", "c", "a"}
+	for i, key := range keys {
+		if key != expected[i] {
+			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
+		}
+	}
+
+	od.Set("d", 4)
+	if od.Has("b") {
+		t.Error("b should be evicted as least recently used")
+	}
+	if !od.Has("a") {
+		t.Error("a should survive after being promoted")
+	}
+}
+
+func TestLRUSetExistingPromotes(t *testing.T) {
+	od := NewLRU[string, int](2, nil)
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("a", 10)
+	od.Set("c", 3)
+
+	if od.Has("b") {
+		t.Error("b should be evicted after a was updated")
+	}
+	if val, _ := od.Peek("a"); val != 10 {
+		t.Errorf("expected a=10, got %d", val)
+	}
+}
+
+func TestLRUPeekDoesNotPromote(t *testing.T) {
+	od := NewLRU[string, int](2, nil)
+	od.Set("a", 1)
+	od.Set("b", 2)
+
+	if val, ok := od.Peek("a"); !ok || val != 1 {
+		t.Fatalf("expected a=1, got %d", val)
+	}
+	od.Set("c", 3)
+
+	if od.Has("a") {
+		t.Error("Peek should not protect a from eviction")
+	}
+	if stats := od.Stats(); stats.Hits != 0 || stats.Misses != 0 {
+		t.Errorf("Peek should not update counters, got %+v", stats)
+	}
+}
+
+func TestLRUEvictCallback(t *testing.T) {
+	var evictedKeys []string
+	var evictedVals []int
+	od := NewLRU(2, func(key string, val int) {
+		evictedKeys = append(evictedKeys, key)
+		evictedVals = append(evictedVals, val)
+	})
+
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
+	od.Set("d", 4)
+
+	if len(evictedKeys) != 2 || evictedKeys[0] != "a" || evictedKeys[1] != "b" {
+		t.Errorf("expected [a b] evicted, got %v", evictedKeys)
+	}
+	if len(evictedVals) != 2 || evictedVals[0] != 1 || evictedVals[1] != 2 {
+		t.Errorf("expected [1 2] evicted values, got %v", evictedVals)
+	}
+}
+
+func TestLRUStats(t *testing.T) {
+	od := NewLRU[string, int](2, nil)
+	od.Set("a", 1)
+	od.Set("b", 2)
+
+	od.Get("a")
+	od.Get("a")
+	od.Get("missing")
+	od.Set("c", 3)
+
+	stats := od.Stats()
+	if stats.Hits != 2 {
+		t.Errorf("expected 2 hits, got %d", stats.Hits)
+	}
+	if stats.Misses != 1 {
+		t.Errorf("expected 1 miss, got %d", stats.Misses)
+	}
+	if stats.Evictions != 1 {
+		t.Errorf("expected 1 eviction, got %d", stats.Evictions)
+	}
+}
+
+func TestLRUMergeEvicts(t *testing.T) {
+	od := NewLRU[int, int](3, nil)
+	od.Set(1, 1)
+
+	other := New[int, int]()
+	for i := 2; i <= 5; i++ {
+		other.Set(i, i)
+	}
+	od.Merge(other)
+
+	keys := od.Keys()
+	expected := []int{3, 4, 5}
+	if len(keys) != len(expected) {
+		t.Fatalf("expected %d keys, got %d", len(expected), len(keys))
+	}
+	for i, key := range keys {
+		if key != expected[i] {
+			t.Errorf("position %d: expected %d, got %d", i, expected[i], key)
+		}
+	}
+}
+
+func TestLRUSetPositioned(t *testing.T) {
+	tests := []struct {
+		name     string
+		set      func(od *OrderedDict[string, int])
+		expected []string
+	}{
+		{"SetFirst", func(od *OrderedDict[string, int]) { od.SetFirst("c", 3) }, []string{"c", "b"}},
+		{"SetBefore first", func(od *OrderedDict[string, int]) { od.SetBefore("a", "c", 3) }, []string{"c", "b"}},
+		{"SetBefore last", func(od *OrderedDict[string, int]) { od.SetBefore("b", "c", 3) }, []string{"c", "b"}},
+		{"SetAfter first", func(od *OrderedDict[string, int]) { od.SetAfter("a", "c", 3) }, []string{"c", "b"}},
+		{"SetAfter last", func(od *OrderedDict[string, int]) { od.SetAfter("b", "c", 3) }, []string{"b", "c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			od := NewLRU[string, int](2, nil)
+			od.Set("a", 1)
+			od.Set("b", 2)
+			tt.set(od)
+
+			if !slices.Equal(od.Keys(), tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, od.Keys())
+			}
+			if stats := od.Stats(); stats.Evictions != 1 {
+				t.Errorf("expected 1 eviction, got %d", stats.Evictions)
+			}
+			checkList(t, od)
+		})
+	}
+}
+
+func TestLRUMergePromotes(t *testing.T) {
+	od := NewLRU[string, int](2, nil)
+	od.Set("a", 1)
+	od.Set("b", 2)
+
+	other := New[string, int]()
+	other.Set("a", 10)
+	other.Set("c", 3)
+	od.Merge(other)
+
+	if !slices.Equal(od.Keys(), []string{"a", "c"}) {
+		t.Errorf("expected [a c], got %v", od.Keys())
+	}
+	if val, _ := od.Peek("a"); val != 10 {
+		t.Errorf("expected a=10, got %d", val)
+	}
+	checkList(t, od)
+}
+
+func TestLRUConcurrent(t *testing.T) {
+	od := NewLRU[int, int](100, nil)
+	var wg sync.WaitGroup
+
+	for i := range 10 {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			for j := range 200 {
+				key := id*200 + j
+				od.Set(key, key)
+				od.Get(key - 1)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	if od.Len() != 100 {
+		t.Errorf("expected len=100, got %d", od.Len())
+	}
+	stats := od.Stats()
+	if stats.Evictions != 1900 {
+		t.Errorf("expected 1900 evictions, got %d", stats.Evictions)
+	}
+	if stats.Hits+stats.Misses != 2000 {
+		t.Errorf("expected 2000 lookups, got %d", stats.Hits+stats.Misses)
+	}
+}
@@ -0,0 +1,63 @@
+package ordereddict
+
+import "time"
+
+// Option configures a dictionary created by New, NewWithCapacity, NewLRU or
+// NewConcurrent.
+type Option func(*options)
+
+type options struct {
+	ttl     time.Duration
+	now     func() time.Time
+	janitor time.Duration
+}
+
+// WithTTL sets a default time-to-live applied to entries added by Set and
+// Merge. A non-positive ttl means entries never expire.
+func WithTTL(ttl time.Duration) Option {
+	return func(opts *options) {
+		opts.ttl = ttl
+	}
+}
+
+// WithClock sets the function used to read the current time when computing
+// and checking expiry. It defaults to time.Now and is mainly useful for
+// tests.
+func WithClock(now func() time.Time) Option {
+	return func(opts *options) {
+		opts.now = now
+	}
+}
+
+// WithJanitor starts a background goroutine that reclaims expired entries
+// every interval. The goroutine runs until Close is called. A non-positive
+// interval disables the janitor.
+func WithJanitor(interval time.Duration) Option {
+	return func(opts *options) {
+		opts.janitor = interval
+	}
+}
+
+// applyOptions collects opts into an options value.
+func applyOptions(opts []Option) options {
+	var cfg options
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// configure applies opts to a newly created dictionary. The janitor is not
+// started for unsynchronized dictionaries.
+func (o *OrderedDict[K, V]) configure(opts []Option) {
+	cfg := applyOptions(opts)
+	o.ttl = cfg.ttl
+	o.now = cfg.now
+	if o.ttl > 0 {
+		o.timed.Store(true)
+	}
+	if cfg.janitor > 0 && !o.unsync {
+		o.stop = make(chan struct{})
+		go o.janitor(cfg.janitor, o.stop)
+	}
+}
@@ -1,18 +1,61 @@
 package ordereddict
 
 import (
+	"container/heap"
 	"fmt"
 	"iter"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type OrderedDict[K comparable, V any] struct {
-	mu   sync.RWMutex
-	data map[K]*node[K, V]
-	head *node[K, V]
-	tail *node[K, V]
-	len  int
+	mu sync.RWMutex
+	s  *store[K, V]
+
+	// LRU mode, enabled by NewLRU. maxLen is 0 for unbounded dictionaries.
+	maxLen  int
+	onEvict func(key K, val V)
+	stats   Stats
+
+	// Expiry, enabled by WithTTL or SetWithTTL. timed is set once the
+	// dictionary may hold expiring entries and never cleared.
+	ttl    time.Duration
+	now    func() time.Time
+	timed  atomic.Bool
+	stop   chan struct{}
+	closed sync.Once
+
+	// inTx is set while Update runs a transaction.
+	inTx bool
+
+	// unsync disables locking for dictionaries owned by an Unsync.
+	unsync bool
+}
+
+// store holds the entries of a dictionary.
+type store[K comparable, V any] struct {
+	data   map[K]*node[K, V]
+	head   *node[K, V]
+	tail   *node[K, V]
+	len    int
+	expiry expiryHeap[K, V]
+
+	// stamp counts the links made, so that iterators can tell which nodes
+	// were linked after they started.
+	stamp uint64
+
+	// Position index, built on the first positional query.
+	indexed bool
+	root    *posNode[K, V]
+
+	// Persistent copy of the entries and their expiry times, built by the
+	// first Snapshot. Keys changed since the last snapshot are collected in
+	// dirty and applied to it by the next one.
+	snap       *ImmutableOrderedDict[K, V]
+	snapExpiry *hamtNode[K, int64]
+	dirty      map[K]struct{}
 }
 
 type node[K comparable, V any] struct {
@@ -20,29 +63,40 @@ type node[K comparable, V any] struct {
 	next *node[K, V]
 	key  K
 	val  V
+
+	// expires is the expiry time in Unix nanoseconds, or 0 if the entry
+	// never expires. hidx is the node's position in the expiry heap.
+	expires int64
+	hidx    int
+
+	// pos is the node's entry in the position index, if it is built.
+	pos *posNode[K, V]
+
+	// linked is the store's stamp when the node was last linked into the
+	// order, and removed is set once it is dropped from the dictionary.
+	// Iterators use them to skip nodes and to move on from the current one.
+	linked  uint64
+	removed bool
 }
 
 // New creates a new OrderedDict.
-func New[K comparable, V any]() *OrderedDict[K, V] {
-	head := &node[K, V]{}
-	tail := &node[K, V]{}
-	head.next = tail
-	tail.prev = head
-	return &OrderedDict[K, V]{
-		data: make(map[K]*node[K, V]),
-		head: head,
-		tail: tail,
-		len:  0,
-	}
+func New[K comparable, V any](opts ...Option) *OrderedDict[K, V] {
+	return NewWithCapacity[K, V](0, opts...)
 }
 
 // NewWithCapacity creates a new OrderedDict with pre-allocated capacity.
-func NewWithCapacity[K comparable, V any](capacity int) *OrderedDict[K, V] {
+func NewWithCapacity[K comparable, V any](capacity int, opts ...Option) *OrderedDict[K, V] {
+	o := &OrderedDict[K, V]{s: newStore[K, V](capacity)}
+	o.configure(opts)
+	return o
+}
+
+func newStore[K comparable, V any](capacity int) *store[K, V] {
 	head := &node[K, V]{}
 	tail := &node[K, V]{}
 	head.next = tail
 	tail.prev = head
-	return &OrderedDict[K, V]{
+	return &store[K, V]{
 		data: make(map[K]*node[K, V], capacity),
 		head: head,
 		tail: tail,
@@ -51,27 +105,109 @@ func NewWithCapacity[K comparable, V any](capacity int) *OrderedDict[K, V] {
 }
 
 // Set adds or updates a key-value pair.
+// If the dictionary has a default TTL, the entry expires after it.
 func (o *OrderedDict[K, V]) Set(key K, val V) {
-	o.mu.Lock()
-	defer o.mu.Unlock()
+	o.lock()
+	defer o.unlock()
+	o.expire()
+	o.set(key, val, o.ttl)
+}
 
-	if existing, ok := o.data[key]; ok {
+// set adds or updates a key-value pair that expires after ttl, or never if
+// ttl is not positive. It must be called with the write lock held.
+func (o *OrderedDict[K, V]) set(key K, val V, ttl time.Duration) {
+	if existing, ok := o.s.data[key]; ok {
 		existing.val = val
+		o.s.touch(key)
+		o.setExpiry(existing, ttl)
+		if o.maxLen > 0 {
+			o.unlinkNode(existing)
+			o.linkToEnd(existing)
+		}
 		return
 	}
 
 	n := &node[K, V]{key: key, val: val}
 	o.linkToEnd(n)
 
-	o.data[key] = n
-	o.len++
+	o.s.data[key] = n
+	o.s.len++
+	o.setExpiry(n, ttl)
+	o.evict()
+}
+
+// SetAfter adds or updates a key-value pair and places it directly after
+// another key, returns false if the after key doesn't exist.
+// An existing key is moved as well as updated.
+func (o *OrderedDict[K, V]) SetAfter(after K, key K, val V) bool {
+	o.lock()
+	defer o.unlock()
+	o.expire()
+	afterNode, ok := o.s.data[after]
+	if !ok {
+		return false
+	}
+	o.setAfter(afterNode, key, val)
+	return true
+}
+
+// SetBefore adds or updates a key-value pair and places it directly before
+// another key, returns false if the before key doesn't exist.
+// An existing key is moved as well as updated.
+func (o *OrderedDict[K, V]) SetBefore(before K, key K, val V) bool {
+	o.lock()
+	defer o.unlock()
+	o.expire()
+	beforeNode, ok := o.s.data[before]
+	if !ok {
+		return false
+	}
+	o.setAfter(beforeNode.prev, key, val)
+	return true
+}
+
+// SetFirst adds or updates a key-value pair and places it at the start of
+// the order. An existing key is moved as well as updated.
+func (o *OrderedDict[K, V]) SetFirst(key K, val V) {
+	o.lock()
+	defer o.unlock()
+	o.expire()
+	o.setAfter(o.s.head, key, val)
+}
+
+// setAfter adds or updates a key-value pair and places it directly after
+// prev, which may be the head sentinel. It must be called with the write
+// lock held.
+func (o *OrderedDict[K, V]) setAfter(prev *node[K, V], key K, val V) {
+	if existing, ok := o.s.data[key]; ok {
+		existing.val = val
+		o.s.touch(key)
+		o.setExpiry(existing, o.ttl)
+		if existing != prev {
+			o.unlinkNode(existing)
+			o.linkAfter(existing, prev)
+		}
+		return
+	}
+
+	n := &node[K, V]{key: key, val: val}
+	o.linkAfter(n, prev)
+
+	o.s.data[key] = n
+	o.s.len++
+	o.setExpiry(n, o.ttl)
+	o.evictExcept(n)
 }
 
 // Get retrieves a value by key, returns false if key doesn't exist.
+// In LRU mode a successful Get also marks the key as most recently used.
 func (o *OrderedDict[K, V]) Get(key K) (V, bool) {
-	o.mu.RLock()
-	defer o.mu.RUnlock()
-	node, ok := o.data[key]
+	if o.maxLen > 0 {
+		return o.getLRU(key)
+	}
+	excl := o.rlock()
+	defer o.runlock(excl)
+	node, ok := o.s.data[key]
 	if !ok {
 		var zero V
 		return zero, false
@@ -82,20 +218,33 @@ func (o *OrderedDict[K, V]) Get(key K) (V, bool) {
 func (o *OrderedDict[K, V]) unlinkNode(n *node[K, V]) {
 	n.prev.next = n.next
 	n.next.prev = n.prev
+	o.unindex(n)
+	o.s.touch(n.key)
+}
+
+// removeNode unlinks a node and drops it from the map and expiry heap.
+func (o *OrderedDict[K, V]) removeNode(n *node[K, V]) {
+	o.unlinkNode(n)
+	n.removed = true
+	delete(o.s.data, n.key)
+	o.s.len--
+	if n.expires != 0 {
+		heap.Remove(&o.s.expiry, n.hidx)
+		n.expires = 0
+	}
 }
 
 // Delete removes a key and returns its value, returns false if key doesn't exist.
 func (o *OrderedDict[K, V]) Delete(key K) (V, bool) {
-	o.mu.Lock()
-	defer o.mu.Unlock()
-	node, ok := o.data[key]
+	o.lock()
+	defer o.unlock()
+	o.expire()
+	node, ok := o.s.data[key]
 	if !ok {
 		var zero V
 		return zero, false // key doesn't exist
 	}
-	o.unlinkNode(node)
-	delete(o.data, key)
-	o.len--
+	o.removeNode(node)
 	return node.val, true
 }
 
@@ -105,27 +254,179 @@ func (o *OrderedDict[K, V]) Remove(key K) bool {
 	return ok
 }
 
+// PopFirst removes and returns the first key-value pair, returns false if
+// the dictionary is empty.
+func (o *OrderedDict[K, V]) PopFirst() (K, V, bool) {
+	return o.PopItem(false)
+}
+
+// PopLast removes and returns the last key-value pair, returns false if the
+// dictionary is empty.
+func (o *OrderedDict[K, V]) PopLast() (K, V, bool) {
+	return o.PopItem(true)
+}
+
+// PopItem removes and returns the last key-value pair if last is true, or
+// the first one otherwise, like Python's OrderedDict.popitem. It returns
+// false if the dictionary is empty.
+func (o *OrderedDict[K, V]) PopItem(last bool) (K, V, bool) {
+	o.lock()
+	defer o.unlock()
+	o.expire()
+	if o.s.len == 0 {
+		var zeroK K
+		var zeroV V
+		return zeroK, zeroV, false
+	}
+	n := o.s.head.next
+	if last {
+		n = o.s.tail.prev
+	}
+	o.removeNode(n)
+	return n.key, n.val, true
+}
+
+// First returns the first key-value pair without removing it, returns false
+// if the dictionary is empty.
+func (o *OrderedDict[K, V]) First() (K, V, bool) {
+	excl := o.rlock()
+	defer o.runlock(excl)
+	return o.entry(o.s.head.next)
+}
+
+// Last returns the last key-value pair without removing it, returns false
+// if the dictionary is empty.
+func (o *OrderedDict[K, V]) Last() (K, V, bool) {
+	excl := o.rlock()
+	defer o.runlock(excl)
+	return o.entry(o.s.tail.prev)
+}
+
+// Next returns the key-value pair that follows key in the order, returns
+// false if key doesn't exist or is the last key.
+func (o *OrderedDict[K, V]) Next(key K) (K, V, bool) {
+	excl := o.rlock()
+	defer o.runlock(excl)
+	node, ok := o.s.data[key]
+	if !ok {
+		return o.entry(o.s.tail)
+	}
+	return o.entry(node.next)
+}
+
+// Prev returns the key-value pair that precedes key in the order, returns
+// false if key doesn't exist or is the first key.
+func (o *OrderedDict[K, V]) Prev(key K) (K, V, bool) {
+	excl := o.rlock()
+	defer o.runlock(excl)
+	node, ok := o.s.data[key]
+	if !ok {
+		return o.entry(o.s.head)
+	}
+	return o.entry(node.prev)
+}
+
+// entry returns the key and value of n, or zero values and false if n is a
+// sentinel.
+func (o *OrderedDict[K, V]) entry(n *node[K, V]) (K, V, bool) {
+	if n == o.s.head || n == o.s.tail {
+		var zeroK K
+		var zeroV V
+		return zeroK, zeroV, false
+	}
+	return n.key, n.val, true
+}
+
+// SetMany adds or updates every key-value pair produced by seq, in order,
+// under a single lock acquisition. The sequence is read in full before the
+// lock is taken, so it may safely iterate over this dictionary.
+func (o *OrderedDict[K, V]) SetMany(seq iter.Seq2[K, V]) {
+	var entries []Entry[K, V]
+	for key, val := range seq {
+		entries = append(entries, Entry[K, V]{key, val})
+	}
+
+	o.lock()
+	defer o.unlock()
+	o.expire()
+	for _, e := range entries {
+		o.set(e.Key, e.Value, o.ttl)
+	}
+}
+
+// DeleteMany removes the given keys under a single lock acquisition and
+// returns the number of keys that existed.
+func (o *OrderedDict[K, V]) DeleteMany(keys ...K) int {
+	o.lock()
+	defer o.unlock()
+	o.expire()
+	deleted := 0
+	for _, key := range keys {
+		if node, ok := o.s.data[key]; ok {
+			o.removeNode(node)
+			deleted++
+		}
+	}
+	return deleted
+}
+
+// GetMany retrieves the given keys under a single lock acquisition. The
+// value and found flag of keys[i] are at index i of the returned slices;
+// missing keys get the zero value. In LRU mode each key is looked up as if
+// by Get.
+func (o *OrderedDict[K, V]) GetMany(keys ...K) ([]V, []bool) {
+	vals := make([]V, len(keys))
+	found := make([]bool, len(keys))
+
+	var excl bool
+	if o.maxLen > 0 {
+		o.lock()
+		o.expire()
+		excl = true
+	} else {
+		excl = o.rlock()
+	}
+	defer o.runlock(excl)
+
+	for i, key := range keys {
+		node, ok := o.s.data[key]
+		if !ok {
+			if o.maxLen > 0 {
+				o.stats.Misses++
+			}
+			continue
+		}
+		if o.maxLen > 0 {
+			o.stats.Hits++
+			o.unlinkNode(node)
+			o.linkToEnd(node)
+		}
+		vals[i], found[i] = node.val, true
+	}
+	return vals, found
+}
+
 // Len returns the number of items in the dictionary.
 func (o *OrderedDict[K, V]) Len() int {
-	o.mu.RLock()
-	defer o.mu.RUnlock()
-	return o.len
+	excl := o.rlock()
+	defer o.runlock(excl)
+	return o.s.len
 }
 
 // Has checks if a key exists in the dictionary.
 func (o *OrderedDict[K, V]) Has(key K) bool {
-	o.mu.RLock()
-	defer o.mu.RUnlock()
-	_, ok := o.data[key]
+	excl := o.rlock()
+	defer o.runlock(excl)
+	_, ok := o.s.data[key]
 	return ok
 }
 
 // Keys returns all keys in insertion order.
 func (o *OrderedDict[K, V]) Keys() []K {
-	o.mu.RLock()
-	defer o.mu.RUnlock()
-	var k []K
-	for curr := o.head.next; curr != o.tail; curr = curr.next {
+	excl := o.rlock()
+	defer o.runlock(excl)
+	k := make([]K, 0, o.s.len)
+	for curr := o.s.head.next; curr != o.s.tail; curr = curr.next {
 		k = append(k, curr.key)
 	}
 	return k
@@ -133,44 +434,209 @@ func (o *OrderedDict[K, V]) Keys() []K {
 
 // Values returns all values in insertion order.
 func (o *OrderedDict[K, V]) Values() []V {
-	o.mu.RLock()
-	defer o.mu.RUnlock()
-	var v []V
-	for curr := o.head.next; curr != o.tail; curr = curr.next {
+	excl := o.rlock()
+	defer o.runlock(excl)
+	v := make([]V, 0, o.s.len)
+	for curr := o.s.head.next; curr != o.s.tail; curr = curr.next {
 		v = append(v, curr.val)
 	}
 	return v
 }
 
 // All returns an iterator over key-value pairs in insertion order.
+//
+// All and the other iterators take the read lock for each step rather than
+// for the whole loop, so the loop body may call any method, including
+// deleting the current key. They visit the entries that were present when
+// the loop started, in their current order, reading each value as it is
+// reached. Entries deleted before the iterator reaches them are skipped, as
+// are entries added or moved while the loop runs. If the current entry is
+// deleted or moved, iteration resumes from the entry that followed it.
 func (o *OrderedDict[K, V]) All() iter.Seq2[K, V] {
 	return func(yield func(K, V) bool) {
-		o.mu.RLock()
-		defer o.mu.RUnlock()
-		for curr := o.head.next; curr != o.tail; curr = curr.next {
-			if !yield(curr.key, curr.val) {
-				return
+		o.walk(false, yield)
+	}
+}
+
+// KeysSeq returns an iterator over keys in insertion order, like All.
+// Unlike Keys, it does not allocate a slice.
+func (o *OrderedDict[K, V]) KeysSeq() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		o.walk(false, func(key K, _ V) bool { return yield(key) })
+	}
+}
+
+// ValuesSeq returns an iterator over values in insertion order, like All.
+// Unlike Values, it does not allocate a slice.
+func (o *OrderedDict[K, V]) ValuesSeq() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		o.walk(false, func(_ K, val V) bool { return yield(val) })
+	}
+}
+
+// Backward returns an iterator over key-value pairs in reverse insertion
+// order, like All.
+func (o *OrderedDict[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		o.walk(true, yield)
+	}
+}
+
+// KeysBackward returns an iterator over keys in reverse insertion order,
+// like All.
+func (o *OrderedDict[K, V]) KeysBackward() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		o.walk(true, func(key K, _ V) bool { return yield(key) })
+	}
+}
+
+// ValuesBackward returns an iterator over values in reverse insertion
+// order, like All.
+func (o *OrderedDict[K, V]) ValuesBackward() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		o.walk(true, func(_ K, val V) bool { return yield(val) })
+	}
+}
+
+// walk calls yield for each entry in order, or in reverse order if backward
+// is true, holding the read lock only while it moves between entries.
+func (o *OrderedDict[K, V]) walk(backward bool, yield func(K, V) bool) {
+	excl := o.rlock()
+	start := o.s.stamp
+	n := o.s.head.next
+	if backward {
+		n = o.s.tail.prev
+	}
+	n = visible(n, backward, start)
+	for n != nil {
+		key, val := n.key, n.val
+		next := visible(n.step(backward), backward, start)
+		o.runlock(excl)
+		if !yield(key, val) {
+			return
+		}
+		excl = o.rlock()
+		if n.removed || n.linked > start {
+			if next == nil {
+				break
 			}
+			n = visible(next, backward, start)
+		} else {
+			n = visible(n.step(backward), backward, start)
 		}
 	}
+	o.runlock(excl)
+}
+
+// visible returns the first node from n onwards, or backwards, that is
+// still in the dictionary and was last linked no later than start, or nil
+// at the end of the order. The links of a removed node still point at its
+// neighbours at the time it was removed, so they lead back into the order.
+func visible[K comparable, V any](n *node[K, V], backward bool, start uint64) *node[K, V] {
+	// Only the sentinels have a nil link.
+	for n.prev != nil && n.next != nil {
+		if !n.removed && n.linked <= start {
+			return n
+		}
+		n = n.step(backward)
+	}
+	return nil
+}
+
+// step returns the node after n, or before it if backward is true.
+func (n *node[K, V]) step(backward bool) *node[K, V] {
+	if backward {
+		return n.prev
+	}
+	return n.next
 }
 
 // Clear removes all items from the dictionary.
 func (o *OrderedDict[K, V]) Clear() {
-	o.mu.Lock()
-	defer o.mu.Unlock()
-	o.head = &node[K, V]{}
-	o.tail = &node[K, V]{}
-	o.head.next = o.tail
-	o.tail.prev = o.head
-	o.len = 0
-	clear(o.data)
+	o.lock()
+	defer o.unlock()
+	for curr := o.s.head.next; curr != o.s.tail; curr = curr.next {
+		curr.removed = true
+		curr.pos = nil
+	}
+	o.s.head.next = o.s.tail
+	o.s.tail.prev = o.s.head
+	o.s.len = 0
+	clear(o.s.data)
+	o.s.expiry = nil
+	o.s.root = nil
+	o.s.dropSnapshot()
+}
+
+// Replace replaces the contents of the dictionary with the entries of src,
+// in src's order, as a single step: concurrent readers see either the old
+// contents or the new ones. Entries take the dictionary's default TTL and,
+// in LRU mode, the oldest are evicted if src holds more than maxLen. src is
+// copied in O(n) under its read lock and is not modified. A nil src empties
+// the dictionary.
+func (o *OrderedDict[K, V]) Replace(src *OrderedDict[K, V]) {
+	if src == o {
+		return
+	}
+	if src == nil {
+		o.Clear()
+		return
+	}
+	excl := src.rlock()
+	s := src.s.clone()
+	src.runlock(excl)
+
+	o.lock()
+	defer o.unlock()
+	o.replace(s)
+}
+
+// replace installs s, which must not be used elsewhere, as the dictionary's
+// store and applies the default TTL and LRU limit to it. It must be called
+// with the write lock held.
+func (o *OrderedDict[K, V]) replace(s *store[K, V]) {
+	if o.s != nil {
+		for curr := o.s.head.next; curr != o.s.tail; curr = curr.next {
+			curr.removed = true
+		}
+	}
+	o.s = s
+	if o.ttl <= 0 && len(o.s.expiry) == 0 && (o.maxLen == 0 || o.s.len <= o.maxLen) {
+		return
+	}
+	for curr := o.s.head.next; curr != o.s.tail; curr = curr.next {
+		o.setExpiry(curr, o.ttl)
+	}
+	o.evict()
 }
 
 // Merge merges another OrderedDict into this one.
 // If keys already exist, their values are updated while maintaining their position.
 // New keys are added at the end in the order they appear in the other dict.
+// Every merged entry is stored as if by Set: it gets the default TTL and,
+// in LRU mode, becomes the most recently used.
+//
+// Merge copies other's entries under its read lock before locking o. It
+// never holds both locks at once, so a.Merge(b) may safely run concurrently
+// with b.Merge(a).
 func (o *OrderedDict[K, V]) Merge(other *OrderedDict[K, V]) {
+	o.merge(other, nil, false)
+}
+
+// MergeWith is like Merge, but for keys present in both dictionaries it
+// stores fn(key, old, new) instead of the value from other. fn runs while
+// the dictionary is locked, so it must not call back into it.
+func (o *OrderedDict[K, V]) MergeWith(other *OrderedDict[K, V], fn func(key K, old, new V) V) {
+	o.merge(other, fn, false)
+}
+
+// MergeToEnd is like Merge, but keys that already exist are moved to the
+// end, so the result ends with other's keys in other's order.
+func (o *OrderedDict[K, V]) MergeToEnd(other *OrderedDict[K, V]) {
+	o.merge(other, nil, true)
+}
+
+func (o *OrderedDict[K, V]) merge(other *OrderedDict[K, V], fn func(key K, old, new V) V, toEnd bool) {
 	if other == nil {
 		return
 	}
@@ -180,37 +646,68 @@ func (o *OrderedDict[K, V]) Merge(other *OrderedDict[K, V]) {
 		return
 	}
 
-	o.mu.Lock()
-	defer o.mu.Unlock()
-	other.mu.RLock()
-	defer other.mu.RUnlock()
+	// Copy other's entries under its read lock, so the two locks are never
+	// held together.
+	entries := other.entries()
 
-	for curr := other.head.next; curr != other.tail; curr = curr.next {
-		if existing, ok := o.data[curr.key]; ok {
-			existing.val = curr.val
-		} else {
-			n := &node[K, V]{key: curr.key, val: curr.val}
-			o.linkToEnd(n)
-			o.data[curr.key] = n
-			o.len++
-		}
+	o.lock()
+	defer o.unlock()
+	o.expire()
+	for _, e := range entries {
+		o.mergeEntry(e.Key, e.Value, fn, toEnd)
+	}
+}
+
+// entries returns a copy of the entries in order, taken under the read
+// lock.
+func (o *OrderedDict[K, V]) entries() []Entry[K, V] {
+	excl := o.rlock()
+	defer o.runlock(excl)
+	entries := make([]Entry[K, V], 0, o.s.len)
+	for curr := o.s.head.next; curr != o.s.tail; curr = curr.next {
+		entries = append(entries, Entry[K, V]{curr.key, curr.val})
+	}
+	return entries
+}
+
+// mergeEntry stores one entry from the dictionary being merged. It goes
+// through set, so an existing key has its expiry refreshed and, in LRU
+// mode, is promoted, just as Set would. It must be called with the write
+// lock held.
+func (o *OrderedDict[K, V]) mergeEntry(key K, val V, fn func(key K, old, new V) V, toEnd bool) {
+	existing, ok := o.s.data[key]
+	if ok && fn != nil {
+		val = fn(key, existing.val, val)
+	}
+	o.set(key, val, o.ttl)
+	if ok && toEnd && o.maxLen == 0 {
+		o.unlinkNode(existing)
+		o.linkToEnd(existing)
 	}
 }
 
 func (o *OrderedDict[K, V]) linkToEnd(n *node[K, V]) {
-	prevTail := o.tail.prev
+	prevTail := o.s.tail.prev
 	n.prev = prevTail
-	n.next = o.tail
+	n.next = o.s.tail
 	prevTail.next = n
-	o.tail.prev = n
+	o.s.tail.prev = n
+	o.index(n, prevTail)
+	o.s.stamp++
+	n.linked = o.s.stamp
+	o.s.touch(n.key)
 }
 
 func (o *OrderedDict[K, V]) linkToStart(n *node[K, V]) {
-	prevHead := o.head.next
+	prevHead := o.s.head.next
 	n.next = prevHead
-	n.prev = o.head
+	n.prev = o.s.head
 	prevHead.prev = n
-	o.head.next = n
+	o.s.head.next = n
+	o.index(n, o.s.head)
+	o.s.stamp++
+	n.linked = o.s.stamp
+	o.s.touch(n.key)
 }
 
 func (o *OrderedDict[K, V]) linkAfter(n *node[K, V], after *node[K, V]) {
@@ -219,13 +716,18 @@ func (o *OrderedDict[K, V]) linkAfter(n *node[K, V], after *node[K, V]) {
 	n.prev = after
 	after.next = n
 	afterNext.prev = n
+	o.index(n, after)
+	o.s.stamp++
+	n.linked = o.s.stamp
+	o.s.touch(n.key)
 }
 
 // MoveToEnd moves a key to the end of the order, returns false if key doesn't exist.
 func (o *OrderedDict[K, V]) MoveToEnd(key K) bool {
-	o.mu.Lock()
-	defer o.mu.Unlock()
-	node, ok := o.data[key]
+	o.lock()
+	defer o.unlock()
+	o.expire()
+	node, ok := o.s.data[key]
 	if !ok {
 		return false
 	}
@@ -236,9 +738,10 @@ func (o *OrderedDict[K, V]) MoveToEnd(key K) bool {
 
 // MoveToStart moves a key to the start of the order, returns false if key doesn't exist.
 func (o *OrderedDict[K, V]) MoveToStart(key K) bool {
-	o.mu.Lock()
-	defer o.mu.Unlock()
-	node, ok := o.data[key]
+	o.lock()
+	defer o.unlock()
+	o.expire()
+	node, ok := o.s.data[key]
 	if !ok {
 		return false
 	}
@@ -247,16 +750,18 @@ func (o *OrderedDict[K, V]) MoveToStart(key K) bool {
 	return true
 }
 
-// MoveAfter moves a key after another key, returns false if either key doesn't exist.
+// MoveAfter moves a key after another key, returns false if either key doesn't
+// exist or both are the same key.
 func (o *OrderedDict[K, V]) MoveAfter(key K, after K) bool {
-	o.mu.Lock()
-	defer o.mu.Unlock()
-	afterNode, ok := o.data[after]
+	o.lock()
+	defer o.unlock()
+	o.expire()
+	afterNode, ok := o.s.data[after]
 	if !ok {
 		return false
 	}
-	node, ok := o.data[key]
-	if !ok {
+	node, ok := o.s.data[key]
+	if !ok || node == afterNode {
 		return false
 	}
 	o.unlinkNode(node)
@@ -264,18 +769,112 @@ func (o *OrderedDict[K, V]) MoveAfter(key K, after K) bool {
 	return true
 }
 
+// MoveBefore moves a key before another key, returns false if either key
+// doesn't exist or both are the same key.
+func (o *OrderedDict[K, V]) MoveBefore(key K, before K) bool {
+	o.lock()
+	defer o.unlock()
+	o.expire()
+	beforeNode, ok := o.s.data[before]
+	if !ok {
+		return false
+	}
+	node, ok := o.s.data[key]
+	if !ok || node == beforeNode {
+		return false
+	}
+	o.unlinkNode(node)
+	o.linkAfter(node, beforeNode.prev)
+	return true
+}
+
+// Swap exchanges the positions of two keys, returns false if either key
+// doesn't exist or both are the same key.
+func (o *OrderedDict[K, V]) Swap(a K, b K) bool {
+	o.lock()
+	defer o.unlock()
+	o.expire()
+	nodeA, ok := o.s.data[a]
+	if !ok {
+		return false
+	}
+	nodeB, ok := o.s.data[b]
+	if !ok || nodeA == nodeB {
+		return false
+	}
+	if nodeB.next == nodeA {
+		nodeA, nodeB = nodeB, nodeA
+	}
+	if nodeA.next == nodeB {
+		o.unlinkNode(nodeA)
+		o.linkAfter(nodeA, nodeB)
+		return true
+	}
+	prevA, prevB := nodeA.prev, nodeB.prev
+	o.unlinkNode(nodeA)
+	o.linkAfter(nodeA, prevB)
+	o.unlinkNode(nodeB)
+	o.linkAfter(nodeB, prevA)
+	return true
+}
+
+// Rotate moves the first n entries to the end of the order, keeping their
+// relative order. A negative n moves the last -n entries to the start
+// instead. n is taken modulo the length, and the cost is proportional to
+// the smaller of n and Len()-n.
+func (o *OrderedDict[K, V]) Rotate(n int) {
+	o.lock()
+	defer o.unlock()
+	o.expire()
+	if o.s.len == 0 {
+		return
+	}
+	n %= o.s.len
+	if n < 0 {
+		n += o.s.len
+	}
+	if n <= o.s.len/2 {
+		for range n {
+			first := o.s.head.next
+			o.unlinkNode(first)
+			o.linkToEnd(first)
+		}
+		return
+	}
+	for range o.s.len - n {
+		last := o.s.tail.prev
+		o.unlinkNode(last)
+		o.linkToStart(last)
+	}
+}
+
+// Reverse reverses the order of the entries in place.
+func (o *OrderedDict[K, V]) Reverse() {
+	o.lock()
+	defer o.unlock()
+	o.expire()
+	o.dropIndex()
+	o.s.dropSnapshot()
+	o.s.stamp++
+	for curr := o.s.head; curr != nil; curr = curr.prev {
+		curr.prev, curr.next = curr.next, curr.prev
+		curr.linked = o.s.stamp
+	}
+	o.s.head, o.s.tail = o.s.tail, o.s.head
+}
+
 // String pretty prints the ordered dict.
 func (o *OrderedDict[K, V]) String() string {
-	o.mu.RLock()
-	defer o.mu.RUnlock()
-	if o.len == 0 {
+	excl := o.rlock()
+	defer o.runlock(excl)
+	if o.s.len == 0 {
 		return "OrderedDict[]"
 	}
 
 	var sb strings.Builder
 	sb.WriteString("OrderedDict[")
 	first := true
-	for curr := o.head.next; curr != o.tail; curr = curr.next {
+	for curr := o.s.head.next; curr != o.s.tail; curr = curr.next {
 		if !first {
 			sb.WriteString(" ")
 		}
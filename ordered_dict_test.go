@@ -1,32 +1,87 @@
 package ordereddict
 
 import (
+	"maps"
+	"slices"
 	"sync"
 	"testing"
+	"time"
 )
 
+// checkList verifies the integrity of the linked list: prev and next
+// pointers agree in both directions, every node is in the map under its own
+// key and the length matches. It also checks the position index if built.
+func checkList[K comparable, V any](t *testing.T, od *OrderedDict[K, V]) {
+	t.Helper()
+	if od.s.head.prev != nil {
+		t.Fatal("head sentinel has a prev pointer")
+	}
+	if od.s.tail.next != nil {
+		t.Fatal("tail sentinel has a next pointer")
+	}
+
+	count := 0
+	for curr := od.s.head.next; curr != od.s.tail; curr = curr.next {
+		if curr == nil {
+			t.Fatal("forward walk reached nil before the tail")
+		}
+		if curr.prev.next != curr {
+			t.Fatalf("node %v: prev.next does not point back", curr.key)
+		}
+		if curr.next.prev != curr {
+			t.Fatalf("node %v: next.prev does not point back", curr.key)
+		}
+		if od.s.data[curr.key] != curr {
+			t.Fatalf("node %v: map entry points to a different node", curr.key)
+		}
+		count++
+		if count > od.s.len {
+			t.Fatalf("forward walk exceeds len=%d, list may contain a cycle", od.s.len)
+		}
+	}
+	if count != od.s.len {
+		t.Fatalf("forward walk found %d nodes, expected len=%d", count, od.s.len)
+	}
+	if len(od.s.data) != od.s.len {
+		t.Fatalf("map holds %d entries, expected len=%d", len(od.s.data), od.s.len)
+	}
+
+	count = 0
+	for curr := od.s.tail.prev; curr != od.s.head; curr = curr.prev {
+		count++
+		if count > od.s.len {
+			t.Fatalf("backward walk exceeds len=%d, list may contain a cycle", od.s.len)
+		}
+	}
+	if count != od.s.len {
+		t.Fatalf("backward walk found %d nodes, expected len=%d", count, od.s.len)
+	}
+
+	checkIndex(t, od)
+}
+
 func TestNew(t *testing.T) {
 	od := New[string, int]()
 
 	if od == nil {
 		t.Fatal("New() returned nil")
 	}
-	if od.data == nil {
+	if od.s.data == nil {
 		t.Fatal("data map not initialized")
 	}
-	if od.head == nil {
+	if od.s.head == nil {
 		t.Fatal("head sentinel not initialized")
 	}
-	if od.tail == nil {
+	if od.s.tail == nil {
 		t.Fatal("tail sentinel not initialized")
 	}
-	if od.len != 0 {
-		t.Errorf("expected len=0, got %d", od.len)
+	if od.s.len != 0 {
+		t.Errorf("expected len=0, got %d", od.s.len)
 	}
-	if od.head.next != od.tail {
+	if od.s.head.next != od.s.tail {
 		t.Error("head.next should point to tail")
 	}
-	if od.tail.prev != od.head {
+	if od.s.tail.prev != od.s.head {
 		t.Error("tail.prev should point to head")
 	}
 }
@@ -37,11 +92,11 @@ func TestNewWithCapacity(t *testing.T) {
 	if od == nil {
 		t.Fatal("NewWithCapacity() returned nil")
 	}
-	if od.data == nil {
+	if od.s.data == nil {
 		t.Fatal("data map not initialized")
 	}
-	if od.len != 0 {
-		t.Errorf("expected len=0, got %d", od.len)
+	if od.s.len != 0 {
+		t.Errorf("expected len=0, got %d", od.s.len)
 	}
 }
 
@@ -49,10 +104,10 @@ func TestSetSingleKey(t *testing.T) {
 	od := New[string, int]()
 	od.Set("key1", 100)
 
-	if od.len != 1 {
-		t.Errorf("expected len=1, got %d", od.len)
+	if od.s.len != 1 {
+		t.Errorf("expected len=1, got %d", od.s.len)
 	}
-	if node, ok := od.data["key1"]; !ok {
+	if node, ok := od.s.data["key1"]; !ok {
 		t.Error("key1 not found in map")
 	} else if node.val != 100 {
 		t.Errorf("expected value=100, got %d", node.val)
@@ -64,10 +119,10 @@ func TestSetUpdateExistingKey(t *testing.T) {
 	od.Set("key1", 100)
 	od.Set("key1", 200)
 
-	if od.len != 1 {
-		t.Errorf("expected len=1 after update, got %d", od.len)
+	if od.s.len != 1 {
+		t.Errorf("expected len=1 after update, got %d", od.s.len)
 	}
-	if node, ok := od.data["key1"]; !ok {
+	if node, ok := od.s.data["key1"]; !ok {
 		t.Error("key1 not found in map")
 	} else if node.val != 200 {
 		t.Errorf("expected updated value=200, got %d", node.val)
@@ -80,8 +135,8 @@ func TestSetMultipleKeys(t *testing.T) {
 	od.Set("second", 2)
 	od.Set("third", 3)
 
-	if od.len != 3 {
-		t.Errorf("expected len=3, got %d", od.len)
+	if od.s.len != 3 {
+		t.Errorf("expected len=3, got %d", od.s.len)
 	}
 
 	expected := map[string]int{
@@ -91,7 +146,7 @@ func TestSetMultipleKeys(t *testing.T) {
 	}
 
 	for key, expectedVal := range expected {
-		if node, ok := od.data[key]; !ok {
+		if node, ok := od.s.data[key]; !ok {
 			t.Errorf("key %s not found in map", key)
 		} else if node.val != expectedVal {
 			t.Errorf("key %s: expected value=%d, got %d", key, expectedVal, node.val)
@@ -106,7 +161,7 @@ func TestSetInsertionOrder(t *testing.T) {
 	od.Set("third", 3)
 
 	keys := []string{}
-	for node := od.head.next; node != od.tail; node = node.next {
+	for node := od.s.head.next; node != od.s.tail; node = node.next {
 		keys = append(keys, node.key)
 	}
 
@@ -129,12 +184,12 @@ func TestSetUpdatePreservesOrder(t *testing.T) {
 	od.Set("third", 3)
 	od.Set("second", 200)
 
-	if od.len != 3 {
-		t.Errorf("expected len=3, got %d", od.len)
+	if od.s.len != 3 {
+		t.Errorf("expected len=3, got %d", od.s.len)
 	}
 
 	keys := []string{}
-	for node := od.head.next; node != od.tail; node = node.next {
+	for node := od.s.head.next; node != od.s.tail; node = node.next {
 		keys = append(keys, node.key)
 	}
 
@@ -145,7 +200,7 @@ func TestSetUpdatePreservesOrder(t *testing.T) {
 		}
 	}
 
-	if node := od.data["second"]; node.val != 200 {
+	if node := od.s.data["second"]; node.val != 200 {
 		t.Errorf("expected updated value=200, got %d", node.val)
 	}
 }
@@ -234,6 +289,356 @@ func TestHas(t *testing.T) {
 	}
 }
 
+func TestPopFirst(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
+
+	key, val, ok := od.PopFirst()
+	if !ok {
+		t.Fatal("expected PopFirst to succeed")
+	}
+	if key != "a" || val != 1 {
+		t.Errorf("expected a:1, got %s:%d", key, val)
+	}
+	checkList(t, od)
+
+	if od.Has("a") {
+		t.Error("popped key should not exist")
+	}
+	if od.Len() != 2 {
+		t.Errorf("expected len=2, got %d", od.Len())
+	}
+
+	keys := od.Keys()
+	expected := []string{"b", "c"}
+	for i, key := range keys {
+		if key != expected[i] {
+			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
+		}
+	}
+}
+
+func TestPopLast(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
+
+	key, val, ok := od.PopLast()
+	if !ok {
+		t.Fatal("expected PopLast to succeed")
+	}
+	if key != "c" || val != 3 {
+		t.Errorf("expected c:3, got %s:%d", key, val)
+	}
+	checkList(t, od)
+
+	if od.Has("c") {
+		t.Error("popped key should not exist")
+	}
+
+	keys := od.Keys()
+	expected := []string{"a", "b"}
+	if len(keys) != len(expected) {
+		t.Fatalf("expected %d keys, got %d", len(expected), len(keys))
+	}
+	for i, key := range keys {
+		if key != expected[i] {
+			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
+		}
+	}
+}
+
+func TestPopEmpty(t *testing.T) {
+	od := New[string, int]()
+
+	if key, val, ok := od.PopFirst(); ok || key != "" || val != 0 {
+		t.Error("expected PopFirst on empty dict to return zero values and false")
+	}
+	if key, val, ok := od.PopLast(); ok || key != "" || val != 0 {
+		t.Error("expected PopLast on empty dict to return zero values and false")
+	}
+}
+
+func TestPopItem(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
+
+	if key, _, _ := od.PopItem(true); key != "c" {
+		t.Errorf("expected PopItem(true) to return c, got %s", key)
+	}
+	if key, _, _ := od.PopItem(false); key != "a" {
+		t.Errorf("expected PopItem(false) to return a, got %s", key)
+	}
+	if key, _, _ := od.PopItem(true); key != "b" {
+		t.Errorf("expected PopItem(true) to return b, got %s", key)
+	}
+	if _, _, ok := od.PopItem(true); ok {
+		t.Error("expected PopItem on empty dict to fail")
+	}
+	checkList(t, od)
+}
+
+func TestPopConcurrent(t *testing.T) {
+	od := New[int, int]()
+	for i := range 1000 {
+		od.Set(i, i)
+	}
+
+	var mu sync.Mutex
+	seen := make(map[int]bool)
+	var wg sync.WaitGroup
+
+	for i := range 10 {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			for {
+				var key int
+				var ok bool
+				if id%2 == 0 {
+					key, _, ok = od.PopFirst()
+				} else {
+					key, _, ok = od.PopLast()
+				}
+				if !ok {
+					return
+				}
+				mu.Lock()
+				if seen[key] {
+					t.Errorf("key %d popped twice", key)
+				}
+				seen[key] = true
+				mu.Unlock()
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	if len(seen) != 1000 {
+		t.Errorf("expected 1000 distinct keys popped, got %d", len(seen))
+	}
+	if od.Len() != 0 {
+		t.Errorf("expected len=0, got %d", od.Len())
+	}
+}
+
+func TestFirstLast(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
+
+	key, val, ok := od.First()
+	if !ok || key != "a" || val != 1 {
+		t.Errorf("expected First to return a:1, got %s:%d", key, val)
+	}
+	key, val, ok = od.Last()
+	if !ok || key != "c" || val != 3 {
+		t.Errorf("expected Last to return c:3, got %s:%d", key, val)
+	}
+
+	if od.Len() != 3 {
+		t.Errorf("First and Last should not remove entries, got len=%d", od.Len())
+	}
+
+	od.MoveToStart("c")
+	if key, _, _ := od.First(); key != "c" {
+		t.Errorf("expected First to follow reordering, got %s", key)
+	}
+	if key, _, _ := od.Last(); key != "b" {
+		t.Errorf("expected Last to follow reordering, got %s", key)
+	}
+}
+
+func TestFirstLastEmpty(t *testing.T) {
+	od := New[string, int]()
+
+	if key, val, ok := od.First(); ok || key != "" || val != 0 {
+		t.Error("expected First on empty dict to return zero values and false")
+	}
+	if key, val, ok := od.Last(); ok || key != "" || val != 0 {
+		t.Error("expected Last on empty dict to return zero values and false")
+	}
+}
+
+func TestNextPrev(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
+
+	key, val, ok := od.Next("a")
+	if !ok || key != "b" || val != 2 {
+		t.Errorf("expected Next(a) to return b:2, got %s:%d", key, val)
+	}
+	key, val, ok = od.Prev("c")
+	if !ok || key != "b" || val != 2 {
+		t.Errorf("expected Prev(c) to return b:2, got %s:%d", key, val)
+	}
+
+	if _, _, ok := od.Next("c"); ok {
+		t.Error("expected Next of last key to fail")
+	}
+	if _, _, ok := od.Prev("a"); ok {
+		t.Error("expected Prev of first key to fail")
+	}
+	if _, _, ok := od.Next("missing"); ok {
+		t.Error("expected Next of nonexistent key to fail")
+	}
+	if _, _, ok := od.Prev("missing"); ok {
+		t.Error("expected Prev of nonexistent key to fail")
+	}
+}
+
+func TestNextPrevCursor(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
+
+	var forward []string
+	for key, _, ok := od.First(); ok; key, _, ok = od.Next(key) {
+		forward = append(forward, key)
+	}
+	var backward []string
+	for key, _, ok := od.Last(); ok; key, _, ok = od.Prev(key) {
+		backward = append(backward, key)
+	}
+
+	if !slices.Equal(forward, []string{"a", "b", "c"}) {
+		t.Errorf("expected forward walk [a b c], got %v", forward)
+	}
+	if !slices.Equal(backward, []string{"c", "b", "a"}) {
+		t.Errorf("expected backward walk [c b a], got %v", backward)
+	}
+}
+
+func TestSetMany(t *testing.T) {
+	od := New[string, int]()
+	od.Set("b", 0)
+
+	src := New[string, int]()
+	src.Set("a", 1)
+	src.Set("b", 2)
+	src.Set("c", 3)
+
+	od.SetMany(src.All())
+
+	keys := od.Keys()
+	expected := []string{"b", "a", "c"}
+	if len(keys) != len(expected) {
+		t.Fatalf("expected %d keys, got %d", len(expected), len(keys))
+	}
+	for i, key := range keys {
+		if key != expected[i] {
+			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
+		}
+	}
+	if val, _ := od.Get("b"); val != 2 {
+		t.Errorf("expected b=2, got %d", val)
+	}
+	checkList(t, od)
+}
+
+func TestSetManyFromMaps(t *testing.T) {
+	od := New[string, int]()
+	od.SetMany(maps.All(map[string]int{"a": 1}))
+
+	if val, ok := od.Get("a"); !ok || val != 1 {
+		t.Errorf("expected a=1, got %d", val)
+	}
+}
+
+func TestSetManyFromSelf(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+
+	// Reading from the same dict must not deadlock
+	od.SetMany(od.All())
+
+	if od.Len() != 2 {
+		t.Errorf("expected len=2, got %d", od.Len())
+	}
+}
+
+func TestDeleteMany(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
+	od.Set("d", 4)
+
+	deleted := od.DeleteMany("a", "c", "missing", "a")
+	if deleted != 2 {
+		t.Errorf("expected 2 deletions, got %d", deleted)
+	}
+	checkList(t, od)
+
+	keys := od.Keys()
+	expected := []string{"b", "d"}
+	if len(keys) != len(expected) {
+		t.Fatalf("expected %d keys, got %d", len(expected), len(keys))
+	}
+	for i, key := range keys {
+		if key != expected[i] {
+			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
+		}
+	}
+
+	if od.DeleteMany() != 0 {
+		t.Error("expected DeleteMany with no keys to delete nothing")
+	}
+}
+
+func TestGetMany(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
+
+	vals, found := od.GetMany("c", "missing", "a")
+
+	if !slices.Equal(vals, []int{3, 0, 1}) {
+		t.Errorf("expected values [3 0 1], got %v", vals)
+	}
+	if !slices.Equal(found, []bool{true, false, true}) {
+		t.Errorf("expected found [true false true], got %v", found)
+	}
+
+	vals, found = od.GetMany()
+	if len(vals) != 0 || len(found) != 0 {
+		t.Errorf("expected empty results, got %v %v", vals, found)
+	}
+}
+
+func TestGetManyLRU(t *testing.T) {
+	od := NewLRU[string, int](3, nil)
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
+
+	od.GetMany("a", "missing")
+
+	keys := od.Keys()
+	expected := []string{"b", "c", "a"}
+	for i, key := range keys {
+		if key != expected[i] {
+			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
+		}
+	}
+	stats := od.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("expected 1 hit and 1 miss, got %+v", stats)
+	}
+}
+
 func TestLen(t *testing.T) {
 	od := New[string, int]()
 
@@ -280,7 +685,7 @@ func TestDelete(t *testing.T) {
 	}
 
 	keys := []string{}
-	for node := od.head.next; node != od.tail; node = node.next {
+	for node := od.s.head.next; node != od.s.tail; node = node.next {
 		keys = append(keys, node.key)
 	}
 	expected := []string{"a", "c"}
@@ -318,10 +723,10 @@ func TestDeleteAll(t *testing.T) {
 	if od.Len() != 0 {
 		t.Errorf("expected len=0, got %d", od.Len())
 	}
-	if od.head.next != od.tail {
+	if od.s.head.next != od.s.tail {
 		t.Error("head should point to tail in empty dict")
 	}
-	if od.tail.prev != od.head {
+	if od.s.tail.prev != od.s.head {
 		t.Error("tail should point to head in empty dict")
 	}
 }
@@ -432,23 +837,440 @@ func TestAllBreak(t *testing.T) {
 	}
 }
 
-func TestDifferentTypes(t *testing.T) {
-	t.Run("int keys", func(t *testing.T) {
-		od := New[int, string]()
-		od.Set(1, "one")
-		od.Set(2, "two")
+func TestKeysSeq(t *testing.T) {
+	od := New[string, int]()
+	od.Set("first", 1)
+	od.Set("second", 2)
+	od.Set("third", 3)
 
-		val, ok := od.Get(1)
-		if !ok || val != "one" {
-			t.Error("int key failed")
-		}
-	})
+	keys := slices.Collect(od.KeysSeq())
+	expected := []string{"first", "second", "third"}
+	if !slices.Equal(keys, expected) {
+		t.Errorf("expected %v, got %v", expected, keys)
+	}
+}
 
-	t.Run("struct keys", func(t *testing.T) {
-		type Key struct{ id int }
-		od := New[Key, bool]()
-		k1 := Key{1}
-		k2 := Key{2}
+func TestKeysSeqBreak(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
+
+	count := 0
+	for key := range od.KeysSeq() {
+		count++
+		if key == "b" {
+			break
+		}
+	}
+
+	if count != 2 {
+		t.Errorf("expected 2 iterations before break, got %d", count)
+	}
+}
+
+func TestValuesSeq(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 10)
+	od.Set("b", 20)
+	od.Set("c", 30)
+
+	values := slices.Collect(od.ValuesSeq())
+	expected := []int{10, 20, 30}
+	if !slices.Equal(values, expected) {
+		t.Errorf("expected %v, got %v", expected, values)
+	}
+
+	if maxVal := slices.Max(slices.Collect(od.ValuesSeq())); maxVal != 30 {
+		t.Errorf("expected max=30, got %d", maxVal)
+	}
+}
+
+func TestSeqComposesWithMaps(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+
+	m := maps.Collect(od.All())
+	if len(m) != 2 || m["a"] != 1 || m["b"] != 2 {
+		t.Errorf("unexpected map %v", m)
+	}
+
+	sorted := slices.Sorted(od.KeysSeq())
+	if !slices.Equal(sorted, []string{"a", "b"}) {
+		t.Errorf("expected [a b], got %v", sorted)
+	}
+}
+
+func TestSeqDoesNotAllocatePerEntry(t *testing.T) {
+	od := New[int, int]()
+	for i := range 1000 {
+		od.Set(i, i)
+	}
+
+	sum := 0
+	allocs := testing.AllocsPerRun(10, func() {
+		for k := range od.KeysSeq() {
+			sum += k
+		}
+		for v := range od.ValuesSeq() {
+			sum += v
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations, got %v", allocs)
+	}
+}
+
+func TestKeysValuesPreSized(t *testing.T) {
+	od := New[int, int]()
+	for i := range 100 {
+		od.Set(i, i)
+	}
+
+	allocs := testing.AllocsPerRun(10, func() {
+		od.Keys()
+		od.Values()
+	})
+	if allocs != 2 {
+		t.Errorf("expected exactly 2 allocations, got %v", allocs)
+	}
+}
+
+func TestBackward(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
+
+	expectedKeys := []string{"c", "b", "a"}
+	expectedValues := []int{3, 2, 1}
+	count := 0
+
+	for key, value := range od.Backward() {
+		if key != expectedKeys[count] {
+			t.Errorf("position %d: expected key=%s, got %s", count, expectedKeys[count], key)
+		}
+		if value != expectedValues[count] {
+			t.Errorf("position %d: expected value=%d, got %d", count, expectedValues[count], value)
+		}
+		count++
+	}
+
+	if count != 3 {
+		t.Errorf("expected 3 iterations, got %d", count)
+	}
+}
+
+func TestBackwardEmpty(t *testing.T) {
+	od := New[string, int]()
+	count := 0
+
+	for range od.Backward() {
+		count++
+	}
+	for range od.KeysBackward() {
+		count++
+	}
+	for range od.ValuesBackward() {
+		count++
+	}
+
+	if count != 0 {
+		t.Errorf("expected 0 iterations, got %d", count)
+	}
+}
+
+func TestBackwardBreak(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
+
+	count := 0
+	for key := range od.Backward() {
+		count++
+		if key == "b" {
+			break
+		}
+	}
+
+	if count != 2 {
+		t.Errorf("expected 2 iterations before break, got %d", count)
+	}
+}
+
+func TestBackwardAfterMove(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
+	od.MoveToEnd("a")
+	checkList(t, od)
+
+	var keys []string
+	for key := range od.Backward() {
+		keys = append(keys, key)
+	}
+
+	expected := []string{"a", "c", "b"}
+	if len(keys) != len(expected) {
+		t.Fatalf("expected %d keys, got %d", len(expected), len(keys))
+	}
+	for i, key := range keys {
+		if key != expected[i] {
+			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
+		}
+	}
+}
+
+func TestKeysBackward(t *testing.T) {
+	od := New[string, int]()
+	od.Set("first", 1)
+	od.Set("second", 2)
+	od.Set("third", 3)
+
+	var keys []string
+	for key := range od.KeysBackward() {
+		keys = append(keys, key)
+	}
+
+	expected := []string{"third", "second", "first"}
+	if len(keys) != len(expected) {
+		t.Fatalf("expected %d keys, got %d", len(expected), len(keys))
+	}
+	for i, key := range keys {
+		if key != expected[i] {
+			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
+		}
+	}
+}
+
+func TestValuesBackward(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 10)
+	od.Set("b", 20)
+	od.Set("c", 30)
+
+	var values []int
+	for val := range od.ValuesBackward() {
+		values = append(values, val)
+	}
+
+	expected := []int{30, 20, 10}
+	if len(values) != len(expected) {
+		t.Fatalf("expected %d values, got %d", len(expected), len(values))
+	}
+	for i, val := range values {
+		if val != expected[i] {
+			t.Errorf("position %d: expected %d, got %d", i, expected[i], val)
+		}
+	}
+}
+
+func TestDeleteWhileIterating(t *testing.T) {
+	od := New[int, int]()
+	for i := range 10 {
+		od.Set(i, i)
+	}
+
+	var visited []int
+	for k, v := range od.All() {
+		visited = append(visited, k)
+		if v%2 == 0 {
+			od.Delete(k)
+		}
+	}
+
+	if len(visited) != 10 {
+		t.Errorf("expected to visit 10 keys, got %v", visited)
+	}
+	if !slices.Equal(od.Keys(), []int{1, 3, 5, 7, 9}) {
+		t.Errorf("expected [1 3 5 7 9], got %v", od.Keys())
+	}
+	checkList(t, od)
+}
+
+func TestModifyWhileIterating(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
+
+	var keys []string
+	for k := range od.KeysBackward() {
+		keys = append(keys, k)
+		od.MoveToEnd("a")
+		od.Set(k+k, 0)
+		od.Set("b", 20)
+	}
+
+	// a is moved and the doubled keys are added during the loop, so the
+	// loop doesn't visit them.
+	if !slices.Equal(keys, []string{"c", "b"}) {
+		t.Errorf("expected [c b], got %v", keys)
+	}
+	if !slices.Equal(od.Keys(), []string{"b", "c", "cc", "a", "bb"}) {
+		t.Errorf("expected [b c cc a bb], got %v", od.Keys())
+	}
+	if val, _ := od.Get("b"); val != 20 {
+		t.Errorf("expected b=20, got %d", val)
+	}
+	checkList(t, od)
+}
+
+func TestNestedIterators(t *testing.T) {
+	od := New[int, int]()
+	for i := range 3 {
+		od.Set(i, i)
+	}
+
+	// Each inner loop starts after the previous removal.
+	pairs := 0
+	for k := range od.KeysSeq() {
+		for range od.ValuesSeq() {
+			pairs++
+		}
+		od.Remove(k)
+	}
+	if pairs != 6 || od.Len() != 0 {
+		t.Errorf("expected 6 pairs and an empty dict, got %d and %d", pairs, od.Len())
+	}
+}
+
+func TestMoveWhileIterating(t *testing.T) {
+	od := New[int, int]()
+	for i := range 5 {
+		od.Set(i, i)
+	}
+
+	// Moving the current entry ahead of the loop must not revisit it.
+	var keys []int
+	for k := range od.KeysSeq() {
+		keys = append(keys, k)
+		od.MoveToEnd(k)
+	}
+	if !slices.Equal(keys, []int{0, 1, 2, 3, 4}) {
+		t.Errorf("expected [0 1 2 3 4], got %v", keys)
+	}
+
+	keys = nil
+	for k := range od.KeysBackward() {
+		keys = append(keys, k)
+		od.MoveToStart(k)
+	}
+	if !slices.Equal(keys, []int{4, 3, 2, 1, 0}) {
+		t.Errorf("expected [4 3 2 1 0], got %v", keys)
+	}
+	if !slices.Equal(od.Keys(), []int{0, 1, 2, 3, 4}) {
+		t.Errorf("expected [0 1 2 3 4], got %v", od.Keys())
+	}
+	checkList(t, od)
+}
+
+func TestDeleteAheadWhileIterating(t *testing.T) {
+	od := New[int, int]()
+	for i := range 6 {
+		od.Set(i, i)
+	}
+
+	var keys []int
+	for k, v := range od.All() {
+		keys = append(keys, k)
+		if k == 1 {
+			od.Delete(1)
+			od.Delete(2)
+			od.Set(10, 10)
+			od.Set(3, 30)
+		}
+		if k == 3 && v != 30 {
+			t.Errorf("expected the loop to read 3=30, got %d", v)
+		}
+	}
+	if !slices.Equal(keys, []int{0, 1, 3, 4, 5}) {
+		t.Errorf("expected [0 1 3 4 5], got %v", keys)
+	}
+	checkList(t, od)
+}
+
+func TestClearWhileIterating(t *testing.T) {
+	od := New[int, int]()
+	for i := range 3 {
+		od.Set(i, i)
+	}
+
+	var keys []int
+	for k := range od.KeysSeq() {
+		keys = append(keys, k)
+		od.Clear()
+		od.Set(10, 10)
+	}
+	if !slices.Equal(keys, []int{0}) {
+		t.Errorf("expected [0], got %v", keys)
+	}
+
+	src := New[int, int]()
+	src.Set(20, 20)
+	keys = nil
+	for k := range od.KeysSeq() {
+		keys = append(keys, k)
+		od.Replace(src)
+	}
+	if !slices.Equal(keys, []int{10}) || !slices.Equal(od.Keys(), []int{20}) {
+		t.Errorf("expected to visit [10] and end with [20], got %v and %v", keys, od.Keys())
+	}
+	checkList(t, od)
+}
+
+func TestModifyWhileIteratingConcurrent(t *testing.T) {
+	od := New[int, int]()
+	for i := range 100 {
+		od.Set(i, i)
+	}
+
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			for range 20 {
+				for k, v := range od.All() {
+					if k != v {
+						t.Errorf("key %d has value %d", k, v)
+						return
+					}
+					if k%8 == id {
+						od.MoveToStart(k)
+					}
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if od.Len() != 100 {
+		t.Errorf("expected len=100, got %d", od.Len())
+	}
+	checkList(t, od)
+}
+
+func TestDifferentTypes(t *testing.T) {
+	t.Run("int keys", func(t *testing.T) {
+		od := New[int, string]()
+		od.Set(1, "one")
+		od.Set(2, "two")
+
+		val, ok := od.Get(1)
+		if !ok || val != "one" {
+			t.Error("int key failed")
+		}
+	})
+
+	t.Run("struct keys", func(t *testing.T) {
+		type Key struct{ id int }
+		od := New[Key, bool]()
+		k1 := Key{1}
+		k2 := Key{2}
 		od.Set(k1, true)
 		od.Set(k2, false)
 
@@ -499,90 +1321,441 @@ func TestConcurrentMixed(t *testing.T) {
 		}()
 	}
 
-	wg.Wait()
+	wg.Wait()
+}
+
+func TestZeroValues(t *testing.T) {
+	od := New[string, int]()
+	od.Set("zero", 0)
+	od.Set("", 42)
+
+	val, ok := od.Get("zero")
+	if !ok || val != 0 {
+		t.Error("zero value storage failed")
+	}
+
+	val, ok = od.Get("")
+	if !ok || val != 42 {
+		t.Error("empty key failed")
+	}
+}
+
+func TestClear(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
+
+	if od.Len() != 3 {
+		t.Errorf("expected len=3, got %d", od.Len())
+	}
+
+	od.Clear()
+
+	if od.Len() != 0 {
+		t.Errorf("expected len=0 after clear, got %d", od.Len())
+	}
+
+	if od.Has("a") || od.Has("b") || od.Has("c") {
+		t.Error("keys should not exist after clear")
+	}
+
+	keys := od.Keys()
+	if len(keys) != 0 {
+		t.Errorf("expected 0 keys after clear, got %d", len(keys))
+	}
+
+	// Verify can add items after clear
+	od.Set("new", 100)
+	if od.Len() != 1 {
+		t.Errorf("expected len=1 after adding to cleared dict, got %d", od.Len())
+	}
+	val, ok := od.Get("new")
+	if !ok || val != 100 {
+		t.Error("failed to add item after clear")
+	}
+}
+
+func TestReplace(t *testing.T) {
+	od := New[string, int]()
+	od.Set("old", 1)
+
+	src := New[string, int]()
+	src.Set("b", 2)
+	src.Set("a", 1)
+	od.Replace(src)
+
+	if !slices.Equal(od.Keys(), []string{"b", "a"}) {
+		t.Errorf("expected [b a], got %v", od.Keys())
+	}
+
+	// The two dictionaries no longer affect each other.
+	od.Set("c", 3)
+	src.Delete("b")
+	if !slices.Equal(od.Keys(), []string{"b", "a", "c"}) || !slices.Equal(src.Keys(), []string{"a"}) {
+		t.Errorf("unexpected contents %v and %v", od, src)
+	}
+	checkList(t, od)
+	checkList(t, src)
+
+	od.Replace(nil)
+	if od.Len() != 0 {
+		t.Errorf("expected Replace(nil) to empty the dict, got %v", od)
+	}
+}
+
+func TestReplaceLRUAndTTL(t *testing.T) {
+	clock := newFakeClock()
+	src := New[int, int]()
+	src.SetWithTTL(1, 1, time.Hour)
+	src.Set(2, 2)
+	src.Set(3, 3)
+
+	od := NewLRU[int, int](2, nil, WithTTL(time.Second), WithClock(clock.Now))
+	od.Replace(src)
+	if !slices.Equal(od.Keys(), []int{2, 3}) {
+		t.Errorf("expected [2 3], got %v", od.Keys())
+	}
+
+	clock.Advance(time.Second)
+	if od.Len() != 0 {
+		t.Errorf("expected entries to take the default TTL, got %v", od)
+	}
+	if src.Len() != 3 {
+		t.Errorf("expected src to be unchanged, got %v", src)
+	}
+}
+
+func TestClearEmpty(t *testing.T) {
+	od := New[string, int]()
+	od.Clear()
+
+	if od.Len() != 0 {
+		t.Errorf("expected len=0, got %d", od.Len())
+	}
+}
+
+func TestRemove(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
+
+	ok := od.Remove("b")
+	if !ok {
+		t.Error("expected remove to succeed")
+	}
+	if od.Len() != 2 {
+		t.Errorf("expected len=2, got %d", od.Len())
+	}
+
+	if od.Has("b") {
+		t.Error("key b should not exist after remove")
+	}
+
+	keys := od.Keys()
+	expected := []string{"a", "c"}
+	for i, key := range keys {
+		if key != expected[i] {
+			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
+		}
+	}
+}
+
+func TestRemoveNonexistent(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+
+	ok := od.Remove("nonexistent")
+	if ok {
+		t.Error("expected remove to return false for nonexistent key")
+	}
+	if od.Len() != 1 {
+		t.Errorf("expected len=1, got %d", od.Len())
+	}
+}
+
+func TestMoveToEnd(t *testing.T) {
+	od := New[string, int]()
+	od.Set("first", 1)
+	od.Set("second", 2)
+	od.Set("third", 3)
+
+	ok := od.MoveToEnd("first")
+	if !ok {
+		t.Error("expected MoveToEnd to succeed")
+	}
+	checkList(t, od)
+
+	keys := od.Keys()
+	expected := []string{"second", "third", "first"}
+	if len(keys) != len(expected) {
+		t.Fatalf("expected %d keys, got %d", len(expected), len(keys))
+	}
+	for i, key := range keys {
+		if key != expected[i] {
+			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
+		}
+	}
+
+	// Verify value is preserved
+	val, ok := od.Get("first")
+	if !ok || val != 1 {
+		t.Error("value should be preserved after move")
+	}
+
+	// Verify length unchanged
+	if od.Len() != 3 {
+		t.Errorf("expected len=3, got %d", od.Len())
+	}
+}
+
+func TestMoveToEndAlreadyAtEnd(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
+
+	ok := od.MoveToEnd("c")
+	if !ok {
+		t.Error("expected MoveToEnd to succeed even when already at end")
+	}
+	checkList(t, od)
+
+	keys := od.Keys()
+	expected := []string{"a", "b", "c"}
+	for i, key := range keys {
+		if key != expected[i] {
+			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
+		}
+	}
+}
+
+func TestMoveToEndNonexistent(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+
+	ok := od.MoveToEnd("nonexistent")
+	if ok {
+		t.Error("expected MoveToEnd to return false for nonexistent key")
+	}
+	checkList(t, od)
+
+	if od.Len() != 1 {
+		t.Errorf("expected len=1, got %d", od.Len())
+	}
+}
+
+func TestMoveToEndSingleElement(t *testing.T) {
+	od := New[string, int]()
+	od.Set("only", 42)
+
+	ok := od.MoveToEnd("only")
+	if !ok {
+		t.Error("expected MoveToEnd to succeed")
+	}
+	checkList(t, od)
+
+	if od.Len() != 1 {
+		t.Errorf("expected len=1, got %d", od.Len())
+	}
+
+	keys := od.Keys()
+	if len(keys) != 1 || keys[0] != "only" {
+		t.Error("single element should remain")
+	}
+}
+
+func TestMoveToStart(t *testing.T) {
+	od := New[string, int]()
+	od.Set("first", 1)
+	od.Set("second", 2)
+	od.Set("third", 3)
+
+	ok := od.MoveToStart("third")
+	if !ok {
+		t.Error("expected MoveToStart to succeed")
+	}
+	checkList(t, od)
+
+	keys := od.Keys()
+	expected := []string{"third", "first", "second"}
+	if len(keys) != len(expected) {
+		t.Fatalf("expected %d keys, got %d", len(expected), len(keys))
+	}
+	for i, key := range keys {
+		if key != expected[i] {
+			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
+		}
+	}
+
+	// Verify value is preserved
+	val, ok := od.Get("third")
+	if !ok || val != 3 {
+		t.Error("value should be preserved after move")
+	}
+
+	// Verify length unchanged
+	if od.Len() != 3 {
+		t.Errorf("expected len=3, got %d", od.Len())
+	}
+}
+
+func TestMoveToStartAlreadyAtStart(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
+
+	ok := od.MoveToStart("a")
+	if !ok {
+		t.Error("expected MoveToStart to succeed even when already at start")
+	}
+	checkList(t, od)
+
+	keys := od.Keys()
+	expected := []string{"a", "b", "c"}
+	for i, key := range keys {
+		if key != expected[i] {
+			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
+		}
+	}
 }
 
-func TestZeroValues(t *testing.T) {
+func TestMoveToStartNonexistent(t *testing.T) {
 	od := New[string, int]()
-	od.Set("zero", 0)
-	od.Set("", 42)
+	od.Set("a", 1)
 
-	val, ok := od.Get("zero")
-	if !ok || val != 0 {
-		t.Error("zero value storage failed")
+	ok := od.MoveToStart("nonexistent")
+	if ok {
+		t.Error("expected MoveToStart to return false for nonexistent key")
 	}
+	checkList(t, od)
 
-	val, ok = od.Get("")
-	if !ok || val != 42 {
-		t.Error("empty key failed")
+	if od.Len() != 1 {
+		t.Errorf("expected len=1, got %d", od.Len())
 	}
 }
 
-func TestClear(t *testing.T) {
+func TestMoveToStartSingleElement(t *testing.T) {
 	od := New[string, int]()
-	od.Set("a", 1)
-	od.Set("b", 2)
-	od.Set("c", 3)
+	od.Set("only", 42)
 
-	if od.Len() != 3 {
-		t.Errorf("expected len=3, got %d", od.Len())
+	ok := od.MoveToStart("only")
+	if !ok {
+		t.Error("expected MoveToStart to succeed")
 	}
+	checkList(t, od)
 
-	od.Clear()
+	if od.Len() != 1 {
+		t.Errorf("expected len=1, got %d", od.Len())
+	}
 
-	if od.Len() != 0 {
-		t.Errorf("expected len=0 after clear, got %d", od.Len())
+	keys := od.Keys()
+	if len(keys) != 1 || keys[0] != "only" {
+		t.Error("single element should remain")
 	}
+}
 
-	if od.Has("a") || od.Has("b") || od.Has("c") {
-		t.Error("keys should not exist after clear")
+func TestMoveAfter(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
+	od.Set("d", 4)
+
+	// Move "a" after "c": should become b, c, a, d
+	ok := od.MoveAfter("a", "c")
+	if !ok {
+		t.Error("expected MoveAfter to succeed")
 	}
+	checkList(t, od)
 
 	keys := od.Keys()
-	if len(keys) != 0 {
-		t.Errorf("expected 0 keys after clear, got %d", len(keys))
+	expected := []string{"b", "c", "a", "d"}
+	if len(keys) != len(expected) {
+		t.Fatalf("expected %d keys, got %d", len(expected), len(keys))
+	}
+	for i, key := range keys {
+		if key != expected[i] {
+			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
+		}
 	}
 
-	// Verify can add items after clear
-	od.Set("new", 100)
-	if od.Len() != 1 {
-		t.Errorf("expected len=1 after adding to cleared dict, got %d", od.Len())
+	// Verify value is preserved
+	val, ok := od.Get("a")
+	if !ok || val != 1 {
+		t.Error("value should be preserved after move")
 	}
-	val, ok := od.Get("new")
-	if !ok || val != 100 {
-		t.Error("failed to add item after clear")
+
+	// Verify length unchanged
+	if od.Len() != 4 {
+		t.Errorf("expected len=4, got %d", od.Len())
 	}
 }
 
-func TestClearEmpty(t *testing.T) {
+func TestMoveAfterToEnd(t *testing.T) {
 	od := New[string, int]()
-	od.Clear()
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
 
-	if od.Len() != 0 {
-		t.Errorf("expected len=0, got %d", od.Len())
+	// Move "a" after "c" (last element): should become b, c, a
+	ok := od.MoveAfter("a", "c")
+	if !ok {
+		t.Error("expected MoveAfter to succeed")
+	}
+	checkList(t, od)
+
+	keys := od.Keys()
+	expected := []string{"b", "c", "a"}
+	for i, key := range keys {
+		if key != expected[i] {
+			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
+		}
 	}
 }
 
-func TestRemove(t *testing.T) {
+func TestMoveAfterAdjacentElements(t *testing.T) {
 	od := New[string, int]()
 	od.Set("a", 1)
 	od.Set("b", 2)
 	od.Set("c", 3)
 
-	ok := od.Remove("b")
+	// Move "c" after "a": should become a, c, b
+	ok := od.MoveAfter("c", "a")
 	if !ok {
-		t.Error("expected remove to succeed")
+		t.Error("expected MoveAfter to succeed")
 	}
-	if od.Len() != 2 {
-		t.Errorf("expected len=2, got %d", od.Len())
+	checkList(t, od)
+
+	keys := od.Keys()
+	expected := []string{"a", "c", "b"}
+	for i, key := range keys {
+		if key != expected[i] {
+			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
+		}
 	}
+}
 
-	if od.Has("b") {
-		t.Error("key b should not exist after remove")
+func TestMoveAfterNonexistentKey(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+
+	ok := od.MoveAfter("nonexistent", "a")
+	if ok {
+		t.Error("expected MoveAfter to return false for nonexistent key")
+	}
+	checkList(t, od)
+
+	if od.Len() != 2 {
+		t.Errorf("expected len=2, got %d", od.Len())
 	}
 
+	// Order should be unchanged
 	keys := od.Keys()
-	expected := []string{"a", "c"}
+	expected := []string{"a", "b"}
 	for i, key := range keys {
 		if key != expected[i] {
 			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
@@ -590,32 +1763,51 @@ func TestRemove(t *testing.T) {
 	}
 }
 
-func TestRemoveNonexistent(t *testing.T) {
+func TestMoveAfterSameKey(t *testing.T) {
 	od := New[string, int]()
 	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
 
-	ok := od.Remove("nonexistent")
+	// Moving "b" after itself is rejected and must not corrupt the list
+	ok := od.MoveAfter("b", "b")
 	if ok {
-		t.Error("expected remove to return false for nonexistent key")
+		t.Error("expected MoveAfter to return false for the same key")
 	}
-	if od.Len() != 1 {
-		t.Errorf("expected len=1, got %d", od.Len())
+	checkList(t, od)
+
+	if od.Len() != 3 {
+		t.Errorf("expected len=3, got %d", od.Len())
+	}
+
+	keys := od.Keys()
+	expected := []string{"a", "b", "c"}
+	if len(keys) != len(expected) {
+		t.Fatalf("expected %d keys, got %d", len(expected), len(keys))
+	}
+	for i, key := range keys {
+		if key != expected[i] {
+			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
+		}
 	}
 }
 
-func TestMoveToEnd(t *testing.T) {
+func TestMoveBefore(t *testing.T) {
 	od := New[string, int]()
-	od.Set("first", 1)
-	od.Set("second", 2)
-	od.Set("third", 3)
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
+	od.Set("d", 4)
 
-	ok := od.MoveToEnd("first")
+	// Move "d" before "b": should become a, d, b, c
+	ok := od.MoveBefore("d", "b")
 	if !ok {
-		t.Error("expected MoveToEnd to succeed")
+		t.Error("expected MoveBefore to succeed")
 	}
+	checkList(t, od)
 
 	keys := od.Keys()
-	expected := []string{"second", "third", "first"}
+	expected := []string{"a", "d", "b", "c"}
 	if len(keys) != len(expected) {
 		t.Fatalf("expected %d keys, got %d", len(expected), len(keys))
 	}
@@ -626,30 +1818,32 @@ func TestMoveToEnd(t *testing.T) {
 	}
 
 	// Verify value is preserved
-	val, ok := od.Get("first")
-	if !ok || val != 1 {
+	val, ok := od.Get("d")
+	if !ok || val != 4 {
 		t.Error("value should be preserved after move")
 	}
 
 	// Verify length unchanged
-	if od.Len() != 3 {
-		t.Errorf("expected len=3, got %d", od.Len())
+	if od.Len() != 4 {
+		t.Errorf("expected len=4, got %d", od.Len())
 	}
 }
 
-func TestMoveToEndAlreadyAtEnd(t *testing.T) {
+func TestMoveBeforeToStart(t *testing.T) {
 	od := New[string, int]()
 	od.Set("a", 1)
 	od.Set("b", 2)
 	od.Set("c", 3)
 
-	ok := od.MoveToEnd("c")
+	// Move "c" before "a" (first element): should become c, a, b
+	ok := od.MoveBefore("c", "a")
 	if !ok {
-		t.Error("expected MoveToEnd to succeed even when already at end")
+		t.Error("expected MoveBefore to succeed")
 	}
+	checkList(t, od)
 
 	keys := od.Keys()
-	expected := []string{"a", "b", "c"}
+	expected := []string{"c", "a", "b"}
 	for i, key := range keys {
 		if key != expected[i] {
 			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
@@ -657,52 +1851,67 @@ func TestMoveToEndAlreadyAtEnd(t *testing.T) {
 	}
 }
 
-func TestMoveToEndNonexistent(t *testing.T) {
+func TestMoveBeforeAdjacentElements(t *testing.T) {
 	od := New[string, int]()
 	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
 
-	ok := od.MoveToEnd("nonexistent")
-	if ok {
-		t.Error("expected MoveToEnd to return false for nonexistent key")
+	// "a" is already before "b": order unchanged
+	if !od.MoveBefore("a", "b") {
+		t.Error("expected MoveBefore to succeed")
 	}
+	checkList(t, od)
+	// Move "b" before "a": should become b, a, c
+	if !od.MoveBefore("b", "a") {
+		t.Error("expected MoveBefore to succeed")
+	}
+	checkList(t, od)
 
-	if od.Len() != 1 {
-		t.Errorf("expected len=1, got %d", od.Len())
+	keys := od.Keys()
+	expected := []string{"b", "a", "c"}
+	for i, key := range keys {
+		if key != expected[i] {
+			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
+		}
 	}
 }
 
-func TestMoveToEndSingleElement(t *testing.T) {
+func TestMoveBeforeNonexistentKey(t *testing.T) {
 	od := New[string, int]()
-	od.Set("only", 42)
+	od.Set("a", 1)
+	od.Set("b", 2)
 
-	ok := od.MoveToEnd("only")
-	if !ok {
-		t.Error("expected MoveToEnd to succeed")
+	if od.MoveBefore("nonexistent", "a") {
+		t.Error("expected MoveBefore to return false for nonexistent key")
 	}
-
-	if od.Len() != 1 {
-		t.Errorf("expected len=1, got %d", od.Len())
+	if od.MoveBefore("a", "nonexistent") {
+		t.Error("expected MoveBefore to return false for nonexistent anchor")
 	}
+	checkList(t, od)
 
 	keys := od.Keys()
-	if len(keys) != 1 || keys[0] != "only" {
-		t.Error("single element should remain")
+	expected := []string{"a", "b"}
+	for i, key := range keys {
+		if key != expected[i] {
+			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
+		}
 	}
 }
 
-func TestMoveToStart(t *testing.T) {
+func TestMoveBeforeSameKey(t *testing.T) {
 	od := New[string, int]()
-	od.Set("first", 1)
-	od.Set("second", 2)
-	od.Set("third", 3)
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
 
-	ok := od.MoveToStart("third")
-	if !ok {
-		t.Error("expected MoveToStart to succeed")
+	if od.MoveBefore("b", "b") {
+		t.Error("expected MoveBefore to return false for the same key")
 	}
+	checkList(t, od)
 
 	keys := od.Keys()
-	expected := []string{"third", "first", "second"}
+	expected := []string{"a", "b", "c"}
 	if len(keys) != len(expected) {
 		t.Fatalf("expected %d keys, got %d", len(expected), len(keys))
 	}
@@ -711,87 +1920,163 @@ func TestMoveToStart(t *testing.T) {
 			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
 		}
 	}
+}
 
-	// Verify value is preserved
-	val, ok := od.Get("third")
-	if !ok || val != 3 {
-		t.Error("value should be preserved after move")
+func TestReorderingIntegrity(t *testing.T) {
+	od := New[int, int]()
+	for i := range 20 {
+		od.Set(i, i)
+	}
+
+	ops := []func(a, b int){
+		func(a, _ int) { od.MoveToEnd(a) },
+		func(a, _ int) { od.MoveToStart(a) },
+		func(a, b int) { od.MoveAfter(a, b) },
+		func(a, b int) { od.MoveBefore(a, b) },
+		func(a, b int) { od.SetAfter(b, a, a) },
+		func(a, b int) { od.SetBefore(b, a, a) },
+		func(a, _ int) { od.SetFirst(a, a) },
+		func(a, b int) { od.Swap(a, b) },
+		func(a, b int) { od.Rotate(a - b) },
+	}
+
+	// Apply every operation to every pair of keys, including a key and
+	// itself, and check the list after each step.
+	for _, op := range ops {
+		for a := range 20 {
+			for b := range 20 {
+				op(a, b)
+				checkList(t, od)
+			}
+		}
 	}
 
-	// Verify length unchanged
-	if od.Len() != 3 {
-		t.Errorf("expected len=3, got %d", od.Len())
+	if od.Len() != 20 {
+		t.Errorf("expected len=20, got %d", od.Len())
 	}
 }
 
-func TestMoveToStartAlreadyAtStart(t *testing.T) {
+func TestSwap(t *testing.T) {
 	od := New[string, int]()
-	od.Set("a", 1)
-	od.Set("b", 2)
-	od.Set("c", 3)
-
-	ok := od.MoveToStart("a")
-	if !ok {
-		t.Error("expected MoveToStart to succeed even when already at start")
+	for i, key := range []string{"a", "b", "c", "d", "e"} {
+		od.Set(key, i)
+	}
+
+	tests := []struct {
+		a, b     string
+		ok       bool
+		expected []string
+	}{
+		{"a", "e", true, []string{"e", "b", "c", "d", "a"}},
+		{"b", "c", true, []string{"e", "c", "b", "d", "a"}},
+		{"d", "b", true, []string{"e", "c", "d", "b", "a"}},
+		{"c", "c", false, []string{"e", "c", "d", "b", "a"}},
+		{"c", "missing", false, []string{"e", "c", "d", "b", "a"}},
+	}
+	for _, tt := range tests {
+		if ok := od.Swap(tt.a, tt.b); ok != tt.ok {
+			t.Errorf("Swap(%s, %s): expected %v, got %v", tt.a, tt.b, tt.ok, ok)
+		}
+		if !slices.Equal(od.Keys(), tt.expected) {
+			t.Errorf("Swap(%s, %s): expected %v, got %v", tt.a, tt.b, tt.expected, od.Keys())
+		}
+		checkList(t, od)
 	}
+	if val, _ := od.Get("a"); val != 0 {
+		t.Errorf("expected values to stay with their keys, got a=%d", val)
+	}
+}
 
-	keys := od.Keys()
-	expected := []string{"a", "b", "c"}
-	for i, key := range keys {
-		if key != expected[i] {
-			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
+func TestRotate(t *testing.T) {
+	tests := []struct {
+		n        int
+		expected []int
+	}{
+		{0, []int{0, 1, 2, 3, 4}},
+		{1, []int{1, 2, 3, 4, 0}},
+		{4, []int{4, 0, 1, 2, 3}},
+		{5, []int{0, 1, 2, 3, 4}},
+		{7, []int{2, 3, 4, 0, 1}},
+		{-1, []int{4, 0, 1, 2, 3}},
+		{-12, []int{3, 4, 0, 1, 2}},
+	}
+	for _, tt := range tests {
+		od := New[int, int]()
+		for i := range 5 {
+			od.Set(i, i)
 		}
+		od.Rotate(tt.n)
+		if !slices.Equal(od.Keys(), tt.expected) {
+			t.Errorf("Rotate(%d): expected %v, got %v", tt.n, tt.expected, od.Keys())
+		}
+		checkList(t, od)
+	}
+
+	empty := New[int, int]()
+	empty.Rotate(3)
+	if empty.Len() != 0 {
+		t.Error("expected Rotate on empty dict to do nothing")
 	}
 }
 
-func TestMoveToStartNonexistent(t *testing.T) {
+func TestReverse(t *testing.T) {
 	od := New[string, int]()
+	od.Reverse()
+	checkList(t, od)
+
 	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
+	od.At(0) // build the position index
+	od.Reverse()
 
-	ok := od.MoveToStart("nonexistent")
-	if ok {
-		t.Error("expected MoveToStart to return false for nonexistent key")
+	if !slices.Equal(od.Keys(), []string{"c", "b", "a"}) {
+		t.Errorf("expected [c b a], got %v", od.Keys())
 	}
+	if pos, _ := od.IndexOf("c"); pos != 0 {
+		t.Errorf("expected c at 0, got %d", pos)
+	}
+	checkList(t, od)
 
-	if od.Len() != 1 {
-		t.Errorf("expected len=1, got %d", od.Len())
+	od.Set("d", 4)
+	od.MoveToStart("a")
+	if !slices.Equal(od.Keys(), []string{"a", "c", "b", "d"}) {
+		t.Errorf("expected [a c b d], got %v", od.Keys())
 	}
+	checkList(t, od)
 }
 
-func TestMoveToStartSingleElement(t *testing.T) {
-	od := New[string, int]()
-	od.Set("only", 42)
-
-	ok := od.MoveToStart("only")
-	if !ok {
-		t.Error("expected MoveToStart to succeed")
+func TestReverseSnapshot(t *testing.T) {
+	od := New[int, int]()
+	for i := range 3 {
+		od.Set(i, i)
 	}
+	snap := od.Snapshot()
+	od.Reverse()
 
-	if od.Len() != 1 {
-		t.Errorf("expected len=1, got %d", od.Len())
+	if !slices.Equal(snap.Keys(), []int{0, 1, 2}) {
+		t.Errorf("expected snapshot to keep [0 1 2], got %v", snap.Keys())
 	}
-
-	keys := od.Keys()
-	if len(keys) != 1 || keys[0] != "only" {
-		t.Error("single element should remain")
+	if !slices.Equal(od.Keys(), []int{2, 1, 0}) {
+		t.Errorf("expected [2 1 0], got %v", od.Keys())
 	}
 }
 
-func TestMoveAfter(t *testing.T) {
+func TestSetAfter(t *testing.T) {
 	od := New[string, int]()
 	od.Set("a", 1)
 	od.Set("b", 2)
 	od.Set("c", 3)
-	od.Set("d", 4)
 
-	// Move "a" after "c": should become b, c, a, d
-	ok := od.MoveAfter("a", "c")
+	// Insert "x" after "a": should become a, x, b, c
+	ok := od.SetAfter("a", "x", 10)
 	if !ok {
-		t.Error("expected MoveAfter to succeed")
+		t.Error("expected SetAfter to succeed")
 	}
+	checkList(t, od)
 
 	keys := od.Keys()
-	expected := []string{"b", "c", "a", "d"}
+	expected := []string{"a", "x", "b", "c"}
 	if len(keys) != len(expected) {
 		t.Fatalf("expected %d keys, got %d", len(expected), len(keys))
 	}
@@ -801,29 +2086,46 @@ func TestMoveAfter(t *testing.T) {
 		}
 	}
 
-	// Verify value is preserved
-	val, ok := od.Get("a")
-	if !ok || val != 1 {
-		t.Error("value should be preserved after move")
+	if val, ok := od.Get("x"); !ok || val != 10 {
+		t.Errorf("expected x=10, got %d", val)
 	}
-
-	// Verify length unchanged
 	if od.Len() != 4 {
 		t.Errorf("expected len=4, got %d", od.Len())
 	}
 }
 
-func TestMoveAfterToEnd(t *testing.T) {
+func TestSetAfterLast(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+
+	od.SetAfter("b", "c", 3)
+	checkList(t, od)
+
+	keys := od.Keys()
+	expected := []string{"a", "b", "c"}
+	for i, key := range keys {
+		if key != expected[i] {
+			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
+		}
+	}
+	if od.s.tail.prev.key != "c" {
+		t.Error("tail.prev should point to the new last node")
+	}
+}
+
+func TestSetAfterExistingKey(t *testing.T) {
 	od := New[string, int]()
 	od.Set("a", 1)
 	od.Set("b", 2)
 	od.Set("c", 3)
 
-	// Move "a" after "c" (last element): should become b, c, a
-	ok := od.MoveAfter("a", "c")
+	// Move "a" after "c" and update it: should become b, c, a
+	ok := od.SetAfter("c", "a", 100)
 	if !ok {
-		t.Error("expected MoveAfter to succeed")
+		t.Error("expected SetAfter to succeed")
 	}
+	checkList(t, od)
 
 	keys := od.Keys()
 	expected := []string{"b", "c", "a"}
@@ -832,78 +2134,170 @@ func TestMoveAfterToEnd(t *testing.T) {
 			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
 		}
 	}
+	if val, _ := od.Get("a"); val != 100 {
+		t.Errorf("expected a=100, got %d", val)
+	}
+	if od.Len() != 3 {
+		t.Errorf("expected len=3, got %d", od.Len())
+	}
 }
 
-func TestMoveAfterAdjacentElements(t *testing.T) {
+func TestSetAfterSameKey(t *testing.T) {
 	od := New[string, int]()
 	od.Set("a", 1)
 	od.Set("b", 2)
 	od.Set("c", 3)
 
-	// Move "c" after "a": should become a, c, b
-	ok := od.MoveAfter("c", "a")
+	ok := od.SetAfter("b", "b", 20)
 	if !ok {
-		t.Error("expected MoveAfter to succeed")
+		t.Error("expected SetAfter to succeed")
 	}
+	checkList(t, od)
 
 	keys := od.Keys()
-	expected := []string{"a", "c", "b"}
+	expected := []string{"a", "b", "c"}
+	if len(keys) != len(expected) {
+		t.Fatalf("expected %d keys, got %d", len(expected), len(keys))
+	}
 	for i, key := range keys {
 		if key != expected[i] {
 			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
 		}
 	}
+	if val, _ := od.Get("b"); val != 20 {
+		t.Errorf("expected b=20, got %d", val)
+	}
 }
 
-func TestMoveAfterNonexistentKey(t *testing.T) {
+func TestSetAfterNonexistentAnchor(t *testing.T) {
 	od := New[string, int]()
 	od.Set("a", 1)
-	od.Set("b", 2)
 
-	ok := od.MoveAfter("nonexistent", "a")
+	ok := od.SetAfter("missing", "x", 10)
 	if ok {
-		t.Error("expected MoveAfter to return false for nonexistent key")
+		t.Error("expected SetAfter to return false for nonexistent anchor")
+	}
+	checkList(t, od)
+	if od.Has("x") {
+		t.Error("key should not be inserted when anchor is missing")
 	}
+	if od.Len() != 1 {
+		t.Errorf("expected len=1, got %d", od.Len())
+	}
+}
 
-	if od.Len() != 2 {
-		t.Errorf("expected len=2, got %d", od.Len())
+func TestSetBefore(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
+
+	// Insert "x" before "a": should become x, a, b, c
+	ok := od.SetBefore("a", "x", 10)
+	if !ok {
+		t.Error("expected SetBefore to succeed")
 	}
+	checkList(t, od)
+	// Insert "y" before "c": should become x, a, b, y, c
+	od.SetBefore("c", "y", 20)
+	checkList(t, od)
 
-	// Order should be unchanged
 	keys := od.Keys()
-	expected := []string{"a", "b"}
+	expected := []string{"x", "a", "b", "y", "c"}
+	if len(keys) != len(expected) {
+		t.Fatalf("expected %d keys, got %d", len(expected), len(keys))
+	}
 	for i, key := range keys {
 		if key != expected[i] {
 			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
 		}
 	}
+	if od.s.head.next.key != "x" {
+		t.Error("head.next should point to the new first node")
+	}
 }
 
-func TestMoveAfterSameKey(t *testing.T) {
+func TestSetBeforeExistingKey(t *testing.T) {
 	od := New[string, int]()
 	od.Set("a", 1)
 	od.Set("b", 2)
 	od.Set("c", 3)
 
-	// Move "b" after "b" - edge case
-	ok := od.MoveAfter("b", "b")
-	if !ok {
-		t.Error("expected MoveAfter to succeed")
+	// Already directly before: order unchanged, value updated
+	od.SetBefore("c", "b", 20)
+	checkList(t, od)
+	// Move "c" before "a": should become c, a, b
+	od.SetBefore("a", "c", 30)
+	checkList(t, od)
+	// Same key: value updated, order unchanged
+	od.SetBefore("a", "a", 10)
+	checkList(t, od)
+
+	keys := od.Keys()
+	expected := []string{"c", "a", "b"}
+	if len(keys) != len(expected) {
+		t.Fatalf("expected %d keys, got %d", len(expected), len(keys))
+	}
+	for i, key := range keys {
+		if key != expected[i] {
+			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
+		}
 	}
 
-	if od.Len() != 3 {
-		t.Errorf("expected len=3, got %d", od.Len())
+	expectedValues := map[string]int{"a": 10, "b": 20, "c": 30}
+	for key, expectedVal := range expectedValues {
+		if val, _ := od.Get(key); val != expectedVal {
+			t.Errorf("key %s: expected value=%d, got %d", key, expectedVal, val)
+		}
 	}
+}
+
+func TestSetBeforeNonexistentAnchor(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+
+	if od.SetBefore("missing", "x", 10) {
+		t.Error("expected SetBefore to return false for nonexistent anchor")
+	}
+	if od.Has("x") {
+		t.Error("key should not be inserted when anchor is missing")
+	}
+}
+
+func TestSetFirst(t *testing.T) {
+	od := New[string, int]()
+	od.SetFirst("c", 3)
+	od.SetFirst("b", 2)
+	od.SetFirst("a", 1)
+	checkList(t, od)
 
-	// After moving "b" after itself (which was deleted first),
-	// the order becomes: a, c, b (b moved to after where it used to be)
 	keys := od.Keys()
-	expected := []string{"a", "c", "b"}
+	expected := []string{"a", "b", "c"}
+	if len(keys) != len(expected) {
+		t.Fatalf("expected %d keys, got %d", len(expected), len(keys))
+	}
+	for i, key := range keys {
+		if key != expected[i] {
+			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
+		}
+	}
+
+	// Existing key is moved to the start and updated
+	od.SetFirst("c", 30)
+	checkList(t, od)
+	keys = od.Keys()
+	expected = []string{"c", "a", "b"}
 	for i, key := range keys {
 		if key != expected[i] {
 			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
 		}
 	}
+	if val, _ := od.Get("c"); val != 30 {
+		t.Errorf("expected c=30, got %d", val)
+	}
+	if od.Len() != 3 {
+		t.Errorf("expected len=3, got %d", od.Len())
+	}
 }
 
 func TestMerge(t *testing.T) {
@@ -1174,6 +2568,104 @@ func TestMergeConcurrent(t *testing.T) {
 	}
 }
 
+func TestMergeWith(t *testing.T) {
+	od1 := New[string, int]()
+	od1.Set("a", 1)
+	od1.Set("b", 2)
+
+	od2 := New[string, int]()
+	od2.Set("b", 20)
+	od2.Set("c", 30)
+
+	var conflicts []string
+	od1.MergeWith(od2, func(key string, old, new int) int {
+		conflicts = append(conflicts, key)
+		return old + new
+	})
+
+	if !slices.Equal(conflicts, []string{"b"}) {
+		t.Errorf("expected fn to be called for [b], got %v", conflicts)
+	}
+	if !slices.Equal(od1.Keys(), []string{"a", "b", "c"}) {
+		t.Errorf("expected [a b c], got %v", od1.Keys())
+	}
+	if !slices.Equal(od1.Values(), []int{1, 22, 30}) {
+		t.Errorf("expected [1 22 30], got %v", od1.Values())
+	}
+}
+
+func TestMergeToEnd(t *testing.T) {
+	od1 := New[string, int]()
+	od1.Set("a", 1)
+	od1.Set("b", 2)
+	od1.Set("c", 3)
+
+	od2 := New[string, int]()
+	od2.Set("d", 4)
+	od2.Set("a", 10)
+
+	od1.MergeToEnd(od2)
+
+	if !slices.Equal(od1.Keys(), []string{"b", "c", "d", "a"}) {
+		t.Errorf("expected [b c d a], got %v", od1.Keys())
+	}
+	if val, _ := od1.Get("a"); val != 10 {
+		t.Errorf("expected a=10, got %d", val)
+	}
+	checkList(t, od1)
+}
+
+func TestMergeCrossConcurrent(t *testing.T) {
+	od1 := New[int, int]()
+	od2 := New[int, int]()
+	for i := range 50 {
+		od1.Set(i, i)
+		od2.Set(i+50, i+50)
+	}
+
+	// Merging in both directions at once must not deadlock.
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if i%2 == 0 {
+				od1.Merge(od2)
+			} else {
+				od1.MergeToEnd(od2)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			od2.MergeWith(od1, func(_ int, old, _ int) int { return old })
+			od2.Set(i, i)
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("cross merges deadlocked")
+	}
+
+	for _, od := range []*OrderedDict[int, int]{od1, od2} {
+		if od.Len() != 100 {
+			t.Errorf("expected len=100, got %d", od.Len())
+		}
+		for k, v := range od.All() {
+			if k != v {
+				t.Errorf("key %d has value %d", k, v)
+			}
+		}
+		checkList(t, od)
+	}
+}
+
 func TestString(t *testing.T) {
 	t.Run("empty dict", func(t *testing.T) {
 		od := New[string, int]()
@@ -1240,3 +2732,156 @@ func TestString(t *testing.T) {
 		}
 	})
 }
+
+func BenchmarkKeys(b *testing.B) {
+	od := New[int, int]()
+	for i := range 10000 {
+		od.Set(i, i)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		for range od.Keys() {
+		}
+	}
+}
+
+func BenchmarkKeysSeq(b *testing.B) {
+	od := New[int, int]()
+	for i := range 10000 {
+		od.Set(i, i)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		for range od.KeysSeq() {
+		}
+	}
+}
+
+// BenchmarkSet and BenchmarkSetMany read the same source dictionary, so
+// they differ only in how the entries are stored.
+
+func BenchmarkSet(b *testing.B) {
+	src := New[int, int]()
+	for i := range 10000 {
+		src.Set(i, i)
+	}
+
+	b.ResetTimer()
+	for range b.N {
+		od := NewWithCapacity[int, int](10000)
+		for key, val := range src.All() {
+			od.Set(key, val)
+		}
+	}
+}
+
+func BenchmarkSetMany(b *testing.B) {
+	src := New[int, int]()
+	for i := range 10000 {
+		src.Set(i, i)
+	}
+
+	b.ResetTimer()
+	for range b.N {
+		od := NewWithCapacity[int, int](10000)
+		od.SetMany(src.All())
+	}
+}
+
+func BenchmarkDelete(b *testing.B) {
+	keys := make([]int, 10000)
+	for i := range keys {
+		keys[i] = i
+	}
+
+	for range b.N {
+		b.StopTimer()
+		od := NewWithCapacity[int, int](10000)
+		for _, key := range keys {
+			od.Set(key, key)
+		}
+		b.StartTimer()
+		for _, key := range keys {
+			od.Delete(key)
+		}
+	}
+}
+
+func BenchmarkDeleteMany(b *testing.B) {
+	keys := make([]int, 10000)
+	for i := range keys {
+		keys[i] = i
+	}
+
+	for range b.N {
+		b.StopTimer()
+		od := NewWithCapacity[int, int](10000)
+		for _, key := range keys {
+			od.Set(key, key)
+		}
+		b.StartTimer()
+		od.DeleteMany(keys...)
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	od := New[int, int]()
+	keys := make([]int, 1000)
+	for i := range keys {
+		keys[i] = i
+		od.Set(i, i)
+	}
+
+	b.ResetTimer()
+	for range b.N {
+		for _, key := range keys {
+			od.Get(key)
+		}
+	}
+}
+
+func BenchmarkGetMany(b *testing.B) {
+	od := New[int, int]()
+	keys := make([]int, 1000)
+	for i := range keys {
+		keys[i] = i
+		od.Set(i, i)
+	}
+
+	b.ResetTimer()
+	for range b.N {
+		od.GetMany(keys...)
+	}
+}
+
+// The parallel variants show the benefit of taking the lock once when
+// other goroutines are contending for it.
+
+func BenchmarkSetParallel(b *testing.B) {
+	od := New[int, int]()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			for i := range 100 {
+				od.Set(i, i)
+			}
+		}
+	})
+}
+
+func BenchmarkSetManyParallel(b *testing.B) {
+	od := New[int, int]()
+	src := New[int, int]()
+	for i := range 100 {
+		src.Set(i, i)
+	}
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			od.SetMany(src.All())
+		}
+	})
+}
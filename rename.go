@@ -0,0 +1,45 @@
+package ordereddict
+
+import "errors"
+
+var (
+	// ErrKeyNotFound is returned by RenameKey when the key to rename
+	// doesn't exist.
+	ErrKeyNotFound = errors.New("ordereddict: key not found")
+
+	// ErrKeyExists is returned by RenameKey when the new key is already in
+	// use.
+	ErrKeyExists = errors.New("ordereddict: key already exists")
+)
+
+// RenameKey changes the key of an entry from old to new, keeping its
+// position, value and expiry. It returns ErrKeyNotFound if old doesn't
+// exist and ErrKeyExists if new already exists; to replace an existing
+// entry, delete it first. Renaming a key to itself does nothing.
+func (o *OrderedDict[K, V]) RenameKey(old, new K) error {
+	o.lock()
+	defer o.unlock()
+	o.expire()
+	return o.renameKey(old, new)
+}
+
+// renameKey implements RenameKey. It must be called with the write lock
+// held.
+func (o *OrderedDict[K, V]) renameKey(old, new K) error {
+	n, ok := o.s.data[old]
+	if !ok {
+		return ErrKeyNotFound
+	}
+	if old == new {
+		return nil
+	}
+	if _, ok := o.s.data[new]; ok {
+		return ErrKeyExists
+	}
+	delete(o.s.data, old)
+	n.key = new
+	o.s.data[new] = n
+	o.s.touch(old)
+	o.s.touch(new)
+	return nil
+}
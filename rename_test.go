@@ -0,0 +1,87 @@
+package ordereddict
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRenameKey(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
+
+	if err := od.RenameKey("b", "beta"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(od.Keys(), []string{"a", "beta", "c"}) {
+		t.Errorf("expected [a beta c], got %v", od.Keys())
+	}
+	if val, ok := od.Get("beta"); !ok || val != 2 {
+		t.Errorf("expected beta=2, got %d", val)
+	}
+	if od.Has("b") {
+		t.Error("expected b to be gone")
+	}
+	checkList(t, od)
+
+	if err := od.RenameKey("a", "a"); err != nil {
+		t.Errorf("expected renaming a key to itself to succeed, got %v", err)
+	}
+	if err := od.RenameKey("missing", "x"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+	if err := od.RenameKey("a", "c"); !errors.Is(err, ErrKeyExists) {
+		t.Errorf("expected ErrKeyExists, got %v", err)
+	}
+	if !slices.Equal(od.Keys(), []string{"a", "beta", "c"}) || od.Len() != 3 {
+		t.Errorf("expected failed renames to change nothing, got %v", od.Keys())
+	}
+}
+
+func TestRenameKeyKeepsExpiry(t *testing.T) {
+	clock := newFakeClock()
+	od := New[string, int](WithClock(clock.Now))
+	od.SetWithTTL("a", 1, time.Second)
+	od.Set("b", 2)
+
+	if err := od.RenameKey("a", "z"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	clock.Advance(time.Second)
+	if od.Has("z") || od.Len() != 1 {
+		t.Errorf("expected the renamed entry to expire, got %v", od.Keys())
+	}
+}
+
+func TestRenameKeyLRU(t *testing.T) {
+	var evicted []string
+	od := NewLRU[string, int](2, func(key string, _ int) {
+		evicted = append(evicted, key)
+	})
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.RenameKey("a", "x")
+	od.Set("c", 3)
+
+	if !slices.Equal(evicted, []string{"x"}) {
+		t.Errorf("expected x to be evicted, got %v", evicted)
+	}
+}
+
+func TestRenameKeySnapshot(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.At(0) // build the position index
+	snap := od.Snapshot()
+
+	od.RenameKey("a", "b")
+	if !snap.Has("a") || snap.Has("b") {
+		t.Error("expected the snapshot to keep the old key")
+	}
+	if pos, ok := od.IndexOf("b"); !ok || pos != 0 {
+		t.Errorf("expected b at 0, got %d", pos)
+	}
+}
@@ -0,0 +1,115 @@
+package ordereddict
+
+import (
+	"fmt"
+	"iter"
+	"strings"
+)
+
+// ReorderOption configures ReorderTo.
+type ReorderOption func(*reorderOptions)
+
+type reorderOptions struct {
+	rejectMissing bool
+}
+
+// RejectMissing makes ReorderTo fail if the key sequence leaves out any key
+// in the dictionary. By default such keys are appended after the listed
+// ones, in their current relative order.
+func RejectMissing() ReorderOption {
+	return func(opts *reorderOptions) {
+		opts.rejectMissing = true
+	}
+}
+
+// ReorderError is returned by ReorderTo when the key sequence does not
+// match the dictionary. Each list is in the order the keys were found.
+type ReorderError[K comparable] struct {
+	Unknown    []K // listed keys that are not in the dictionary
+	Duplicates []K // keys listed more than once
+	Missing    []K // keys left out, only reported with RejectMissing
+}
+
+func (e *ReorderError[K]) Error() string {
+	var parts []string
+	if len(e.Unknown) > 0 {
+		parts = append(parts, fmt.Sprintf("unknown keys %v", e.Unknown))
+	}
+	if len(e.Duplicates) > 0 {
+		parts = append(parts, fmt.Sprintf("duplicate keys %v", e.Duplicates))
+	}
+	if len(e.Missing) > 0 {
+		parts = append(parts, fmt.Sprintf("missing keys %v", e.Missing))
+	}
+	return "ordereddict: cannot reorder: " + strings.Join(parts, ", ")
+}
+
+// ReorderTo rearranges the dictionary so that its keys follow the order of
+// keys. Keys not listed are appended after the listed ones in their current
+// relative order, unless RejectMissing is given. If keys contains unknown or
+// duplicate keys, or leaves some out with RejectMissing, ReorderTo returns a
+// *ReorderError and leaves the order unchanged.
+func (o *OrderedDict[K, V]) ReorderTo(keys []K, opts ...ReorderOption) error {
+	var cfg reorderOptions
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	o.lock()
+	defer o.unlock()
+	o.expire()
+	return o.reorderTo(keys, cfg)
+}
+
+// reorderTo implements ReorderTo. It must be called with the write lock
+// held.
+func (o *OrderedDict[K, V]) reorderTo(keys []K, cfg reorderOptions) error {
+	seen, err := checkReorder(keys, cfg, o.s.len, func(key K) bool {
+		_, ok := o.s.data[key]
+		return ok
+	}, o.s.all())
+	if err != nil {
+		return err
+	}
+
+	o.relink(func(nodes []*node[K, V]) {
+		rest := make([]*node[K, V], 0, len(nodes)-len(keys))
+		for _, n := range nodes {
+			if seen[n.key] == 0 {
+				rest = append(rest, n)
+			}
+		}
+		for i, key := range keys {
+			nodes[i] = o.s.data[key]
+		}
+		copy(nodes[len(keys):], rest)
+	})
+	return nil
+}
+
+// checkReorder checks keys against a dictionary of n entries for which has
+// reports whether a key exists and all lists the keys in order. It returns
+// the number of times each key is listed, or a *ReorderError.
+func checkReorder[K comparable, V any](keys []K, cfg reorderOptions, n int, has func(K) bool, all iter.Seq2[K, V]) (map[K]int, error) {
+	var rerr ReorderError[K]
+	seen := make(map[K]int, len(keys))
+	for _, key := range keys {
+		seen[key]++
+		if seen[key] == 2 {
+			rerr.Duplicates = append(rerr.Duplicates, key)
+		} else if seen[key] == 1 && !has(key) {
+			rerr.Unknown = append(rerr.Unknown, key)
+		}
+	}
+	if cfg.rejectMissing && len(seen)-len(rerr.Unknown) < n {
+		for key := range all {
+			if seen[key] == 0 {
+				rerr.Missing = append(rerr.Missing, key)
+			}
+		}
+	}
+	if rerr.Unknown != nil || rerr.Duplicates != nil || rerr.Missing != nil {
+		return nil, &rerr
+	}
+	return seen, nil
+}
@@ -0,0 +1,121 @@
+package ordereddict
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestReorderTo(t *testing.T) {
+	od := New[string, int]()
+	for i, key := range []string{"a", "b", "c", "d"} {
+		od.Set(key, i)
+	}
+
+	if err := od.ReorderTo([]string{"c", "a", "d", "b"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(od.Keys(), []string{"c", "a", "d", "b"}) {
+		t.Errorf("expected [c a d b], got %v", od.Keys())
+	}
+	if val, _ := od.Get("d"); val != 3 {
+		t.Errorf("expected d=3, got %d", val)
+	}
+	checkList(t, od)
+
+	// Keys left out keep their relative order at the end.
+	if err := od.ReorderTo([]string{"b"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(od.Keys(), []string{"b", "c", "a", "d"}) {
+		t.Errorf("expected [b c a d], got %v", od.Keys())
+	}
+	checkList(t, od)
+}
+
+func TestReorderToErrors(t *testing.T) {
+	od := New[string, int]()
+	for i, key := range []string{"a", "b", "c", "d"} {
+		od.Set(key, i)
+	}
+
+	tests := []struct {
+		name     string
+		keys     []string
+		opts     []ReorderOption
+		expected ReorderError[string]
+		message  string
+	}{
+		{
+			name:     "unknown",
+			keys:     []string{"a", "x", "b", "y", "x"},
+			expected: ReorderError[string]{Unknown: []string{"x", "y"}, Duplicates: []string{"x"}},
+			message:  "ordereddict: cannot reorder: unknown keys [x y], duplicate keys [x]",
+		},
+		{
+			name:     "duplicates",
+			keys:     []string{"a", "b", "a", "a", "b"},
+			expected: ReorderError[string]{Duplicates: []string{"a", "b"}},
+			message:  "ordereddict: cannot reorder: duplicate keys [a b]",
+		},
+		{
+			name:     "missing",
+			keys:     []string{"c", "a", "z"},
+			opts:     []ReorderOption{RejectMissing()},
+			expected: ReorderError[string]{Unknown: []string{"z"}, Missing: []string{"b", "d"}},
+			message:  "ordereddict: cannot reorder: unknown keys [z], missing keys [b d]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := od.ReorderTo(tt.keys, tt.opts...)
+			var rerr *ReorderError[string]
+			if !errors.As(err, &rerr) {
+				t.Fatalf("expected a *ReorderError, got %v", err)
+			}
+			if !slices.Equal(rerr.Unknown, tt.expected.Unknown) ||
+				!slices.Equal(rerr.Duplicates, tt.expected.Duplicates) ||
+				!slices.Equal(rerr.Missing, tt.expected.Missing) {
+				t.Errorf("expected %+v, got %+v", tt.expected, *rerr)
+			}
+			if err.Error() != tt.message {
+				t.Errorf("unexpected message %q", err.Error())
+			}
+			if !slices.Equal(od.Keys(), []string{"a", "b", "c", "d"}) {
+				t.Errorf("expected the order to be unchanged, got %v", od.Keys())
+			}
+		})
+	}
+}
+
+func TestReorderToRejectMissing(t *testing.T) {
+	od := New[int, int]()
+	for i := range 3 {
+		od.Set(i, i)
+	}
+
+	if err := od.ReorderTo([]int{2, 0, 1}, RejectMissing()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(od.Keys(), []int{2, 0, 1}) {
+		t.Errorf("expected [2 0 1], got %v", od.Keys())
+	}
+}
+
+func TestReorderToWithIndex(t *testing.T) {
+	od := New[int, int]()
+	for i := range 10 {
+		od.Set(i, i)
+	}
+	od.At(0) // build the position index
+
+	if err := od.ReorderTo([]int{9, 8, 7}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pos, _ := od.IndexOf(0); pos != 3 {
+		t.Errorf("expected 0 at 3, got %d", pos)
+	}
+	od.MoveToEnd(9)
+	checkList(t, od)
+}
@@ -0,0 +1,256 @@
+package ordereddict
+
+import (
+	"container/heap"
+	"fmt"
+	"iter"
+	"maps"
+	"strings"
+	"time"
+)
+
+// Snapshot is a read-only, point-in-time view of an OrderedDict or a
+// ConcurrentOrderedDict. It is safe for concurrent use and never blocks, or
+// is blocked by, writers to the dictionary it was taken from.
+type Snapshot[K comparable, V any] struct {
+	m *ImmutableOrderedDict[K, V]
+
+	// The expiry times of expiring entries, and the expiry settings of the
+	// source dictionary, passed on by Clone.
+	expiry *hamtNode[K, int64]
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// Snapshot returns a read-only view of the dictionary's current contents.
+// Snapshots are persistent trees that share their structure with each
+// other. The first snapshot copies the entries in O(n); from then on the
+// dictionary notes which keys are written to, and the next snapshot copies
+// only the paths to those keys, in O(log n) each. Entries in a snapshot
+// never expire and reading a snapshot does not affect LRU order.
+func (o *OrderedDict[K, V]) Snapshot() *Snapshot[K, V] {
+	o.lock()
+	defer o.unlock()
+	o.expire()
+	m, expiry := o.s.snapshot()
+	return &Snapshot[K, V]{m: m, expiry: expiry, ttl: o.ttl, now: o.now}
+}
+
+// snapshot brings the store's persistent copy up to date and returns it.
+func (s *store[K, V]) snapshot() (*ImmutableOrderedDict[K, V], *hamtNode[K, int64]) {
+	if s.snap == nil {
+		s.snap = buildImmutable(s.len, s.all())
+		s.snapExpiry = nil
+		for _, n := range s.expiry {
+			s.snapExpiry, _ = s.snapExpiry.set(hamtHash(n.key), n.key, n.expires, 0)
+		}
+		s.dirty = make(map[K]struct{})
+		return s.snap, s.snapExpiry
+	}
+
+	m, expiry := s.snap, s.snapExpiry
+	for key := range s.dirty {
+		m, _, _ = m.Delete(key)
+		expiry, _ = expiry.delete(hamtHash(key), key, 0)
+	}
+	// Add back the changed keys that are still present. Each run of
+	// adjacent changed keys is added in order from its first key, so every
+	// key goes in after an entry that is already in place.
+	for key := range s.dirty {
+		n, ok := s.data[key]
+		if !ok {
+			continue
+		}
+		for n.prev != s.head && s.changed(n.prev) {
+			n = n.prev
+		}
+		for ; n != s.tail && s.changed(n); n = n.next {
+			delete(s.dirty, n.key)
+			var prev *K
+			if n.prev != s.head {
+				prev = &n.prev.key
+			}
+			m = m.insertAfter(prev, n.key, n.val)
+			if n.expires != 0 {
+				expiry, _ = expiry.set(hamtHash(n.key), n.key, n.expires, 0)
+			}
+		}
+	}
+	clear(s.dirty)
+	s.snap, s.snapExpiry = m, expiry
+	return m, expiry
+}
+
+// changed reports whether n is waiting to be added to the persistent copy.
+func (s *store[K, V]) changed(n *node[K, V]) bool {
+	_, ok := s.dirty[n.key]
+	return ok
+}
+
+// touch records that the entry with key has changed since the last
+// snapshot. Once more keys have changed than the store holds, rebuilding
+// the persistent copy is cheaper than updating it, so it is dropped.
+func (s *store[K, V]) touch(key K) {
+	if s.snap == nil {
+		return
+	}
+	s.dirty[key] = struct{}{}
+	if len(s.dirty) > s.len {
+		s.dropSnapshot()
+	}
+}
+
+// dropSnapshot discards the persistent copy. It is rebuilt by the next
+// Snapshot. Like dropIndex, it is used by callers that relink many nodes at
+// once.
+func (s *store[K, V]) dropSnapshot() {
+	s.snap = nil
+	s.snapExpiry = nil
+	s.dirty = nil
+}
+
+// Clone returns a copy of the dictionary with the same LRU and expiry
+// settings but no janitor and zeroed statistics. The entries are copied in
+// O(n) under the read lock.
+func (o *OrderedDict[K, V]) Clone() *OrderedDict[K, V] {
+	excl := o.rlock()
+	defer o.runlock(excl)
+	c := &OrderedDict[K, V]{
+		s:       o.s.clone(),
+		maxLen:  o.maxLen,
+		onEvict: o.onEvict,
+		ttl:     o.ttl,
+		now:     o.now,
+	}
+	c.timed.Store(o.timed.Load())
+	return c
+}
+
+// clone returns a deep copy of the store. The position index is not copied;
+// it is rebuilt by the next positional query. The persistent copy is
+// immutable, so the two stores share it.
+func (s *store[K, V]) clone() *store[K, V] {
+	c := newStore[K, V](s.len)
+	for curr := s.head.next; curr != s.tail; curr = curr.next {
+		c.push(&node[K, V]{key: curr.key, val: curr.val, expires: curr.expires})
+	}
+	heap.Init(&c.expiry)
+	if s.snap != nil {
+		c.snap, c.snapExpiry = s.snap, s.snapExpiry
+		c.dirty = maps.Clone(s.dirty)
+	}
+	return c
+}
+
+// push links n at the end of a store that is being built and adds it to
+// the map and, if it expires, to the expiry heap. The caller must call
+// heap.Init on the expiry heap once every node is added.
+func (s *store[K, V]) push(n *node[K, V]) {
+	n.prev = s.tail.prev
+	n.next = s.tail
+	s.tail.prev.next = n
+	s.tail.prev = n
+	s.data[n.key] = n
+	s.len++
+	if n.expires != 0 {
+		n.hidx = len(s.expiry)
+		s.expiry = append(s.expiry, n)
+	}
+}
+
+// all returns an iterator over the store's entries. The caller must ensure
+// the store is not modified during iteration.
+func (s *store[K, V]) all() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for curr := s.head.next; curr != s.tail; curr = curr.next {
+			if !yield(curr.key, curr.val) {
+				return
+			}
+		}
+	}
+}
+
+// Len returns the number of items in the snapshot.
+func (s *Snapshot[K, V]) Len() int {
+	return s.m.Len()
+}
+
+// Get retrieves a value by key, returns false if key doesn't exist.
+func (s *Snapshot[K, V]) Get(key K) (V, bool) {
+	return s.m.Get(key)
+}
+
+// Has checks if a key exists in the snapshot.
+func (s *Snapshot[K, V]) Has(key K) bool {
+	return s.m.Has(key)
+}
+
+// First returns the first key-value pair, returns false if the snapshot is
+// empty.
+func (s *Snapshot[K, V]) First() (K, V, bool) {
+	return s.m.First()
+}
+
+// Last returns the last key-value pair, returns false if the snapshot is
+// empty.
+func (s *Snapshot[K, V]) Last() (K, V, bool) {
+	return s.m.Last()
+}
+
+// Keys returns all keys in order.
+func (s *Snapshot[K, V]) Keys() []K {
+	return s.m.Keys()
+}
+
+// Values returns all values in order.
+func (s *Snapshot[K, V]) Values() []V {
+	return s.m.Values()
+}
+
+// All returns an iterator over key-value pairs in order.
+func (s *Snapshot[K, V]) All() iter.Seq2[K, V] {
+	return s.m.All()
+}
+
+// Backward returns an iterator over key-value pairs in reverse order.
+func (s *Snapshot[K, V]) Backward() iter.Seq2[K, V] {
+	return s.m.Backward()
+}
+
+// Clone returns a new, writable OrderedDict with the snapshot's contents,
+// copied in O(n). The clone has the TTL and clock of the dictionary the
+// snapshot was taken from, so entries set with a TTL expire from it at the
+// same time as from the original. It has no LRU limit or janitor.
+func (s *Snapshot[K, V]) Clone() *OrderedDict[K, V] {
+	st := newStore[K, V](s.m.Len())
+	for key, val := range s.m.All() {
+		n := &node[K, V]{key: key, val: val}
+		if s.expiry != nil {
+			n.expires, _ = s.expiry.get(hamtHash(key), key)
+		}
+		st.push(n)
+	}
+	heap.Init(&st.expiry)
+	st.snap, st.snapExpiry = s.m, s.expiry
+	st.dirty = make(map[K]struct{})
+
+	c := &OrderedDict[K, V]{s: st, ttl: s.ttl, now: s.now}
+	c.timed.Store(s.ttl > 0 || len(st.expiry) > 0)
+	return c
+}
+
+// String pretty prints the snapshot.
+func (s *Snapshot[K, V]) String() string {
+	var sb strings.Builder
+	sb.WriteString("Snapshot[")
+	first := true
+	for key, val := range s.m.All() {
+		if !first {
+			sb.WriteString(" ")
+		}
+		fmt.Fprintf(&sb, "%v:%v", key, val)
+		first = false
+	}
+	sb.WriteString("]")
+	return sb.String()
+}
@@ -0,0 +1,383 @@
+package ordereddict
+
+import (
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSnapshot(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+
+	snap := od.Snapshot()
+
+	od.Set("a", 10)
+	od.Set("c", 3)
+	od.MoveToStart("b")
+	od.Delete("a")
+
+	if !slices.Equal(snap.Keys(), []string{"a", "b"}) {
+		t.Errorf("expected snapshot keys [a b], got %v", snap.Keys())
+	}
+	if val, ok := snap.Get("a"); !ok || val != 1 {
+		t.Errorf("expected snapshot a=1, got %d", val)
+	}
+	if snap.Has("c") || snap.Len() != 2 {
+		t.Error("snapshot should not see later writes")
+	}
+	if !slices.Equal(od.Keys(), []string{"b", "c"}) {
+		t.Errorf("expected dict keys [b c], got %v", od.Keys())
+	}
+	checkList(t, od)
+}
+
+func TestSnapshotAccessors(t *testing.T) {
+	od := New[string, int]()
+	empty := od.Snapshot()
+	if _, _, ok := empty.First(); ok {
+		t.Error("expected First on empty snapshot to fail")
+	}
+	if empty.String() != "Snapshot[]" {
+		t.Errorf("unexpected String %q", empty.String())
+	}
+
+	od.Set("a", 1)
+	od.Set("b", 2)
+	snap := od.Snapshot()
+
+	if k, v, ok := snap.First(); !ok || k != "a" || v != 1 {
+		t.Errorf("First: expected a:1, got %s:%d", k, v)
+	}
+	if k, v, ok := snap.Last(); !ok || k != "b" || v != 2 {
+		t.Errorf("Last: expected b:2, got %s:%d", k, v)
+	}
+	if !slices.Equal(snap.Values(), []int{1, 2}) {
+		t.Errorf("expected values [1 2], got %v", snap.Values())
+	}
+	var backward []string
+	for k := range snap.Backward() {
+		backward = append(backward, k)
+	}
+	if !slices.Equal(backward, []string{"b", "a"}) {
+		t.Errorf("expected [b a], got %v", backward)
+	}
+	if snap.String() != "Snapshot[a:1 b:2]" {
+		t.Errorf("unexpected String %q", snap.String())
+	}
+}
+
+func TestSnapshotIncremental(t *testing.T) {
+	clock := newFakeClock()
+	od := New[int, int](WithClock(clock.Now))
+	for i := range 10 {
+		od.Set(i, i)
+	}
+
+	first := od.Snapshot()
+	store := od.s
+	od.Set(10, 10)
+	od.Delete(3)
+	od.SetWithTTL(5, 50, time.Second)
+	if od.s != store {
+		t.Fatal("writes after a snapshot should not copy the entries")
+	}
+	if len(od.s.dirty) != 3 {
+		t.Errorf("expected 3 changed keys, got %d", len(od.s.dirty))
+	}
+
+	second := od.Snapshot()
+	if len(od.s.dirty) != 0 {
+		t.Errorf("expected the snapshot to apply the changes, got %d left", len(od.s.dirty))
+	}
+	if !slices.Equal(first.Keys(), []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}) {
+		t.Errorf("first snapshot changed: %v", first.Keys())
+	}
+	if !slices.Equal(second.Keys(), []int{0, 1, 2, 4, 5, 6, 7, 8, 9, 10}) {
+		t.Errorf("unexpected second snapshot keys %v", second.Keys())
+	}
+	if val, _ := second.Get(5); val != 50 {
+		t.Errorf("expected 5=50, got %d", val)
+	}
+
+	c := second.Clone()
+	clock.Advance(time.Second)
+	if c.Has(5) || c.Len() != 9 {
+		t.Errorf("expected 5 to expire from the clone, got %v", c.Keys())
+	}
+	checkList(t, c)
+}
+
+func TestSnapshotRandomOps(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	od := New[int, int]()
+	for step := range 5000 {
+		key, other := r.IntN(50), r.IntN(50)
+		switch r.IntN(9) {
+		case 0, 1:
+			od.Set(key, step)
+		case 2:
+			od.Delete(key)
+		case 3:
+			od.MoveToStart(key)
+		case 4:
+			od.MoveAfter(key, other)
+		case 5:
+			od.SetBefore(other, key, step)
+		case 6:
+			od.RenameKey(key, other)
+		case 7:
+			od.Swap(key, other)
+		case 8:
+			if r.IntN(10) == 0 {
+				od.Reverse()
+			} else {
+				od.PopFirst()
+			}
+		}
+
+		if r.IntN(5) == 0 {
+			snap := od.Snapshot()
+			if !slices.Equal(snap.Keys(), od.Keys()) || !slices.Equal(snap.Values(), od.Values()) {
+				t.Fatalf("step %d: snapshot %v does not match dict %v", step, snap, od)
+			}
+		}
+	}
+}
+
+func TestSnapshotClear(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	snap := od.Snapshot()
+	od.Clear()
+
+	if snap.Len() != 1 || !snap.Has("a") {
+		t.Error("Clear should not affect the snapshot")
+	}
+	if od.Len() != 0 {
+		t.Errorf("expected len=0, got %d", od.Len())
+	}
+	od.Set("b", 2)
+	checkList(t, od)
+}
+
+func TestSnapshotWithIndex(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
+
+	snap := od.Snapshot()
+	od.At(0) // build the position index
+	od.MoveToStart("c")
+	checkList(t, od)
+
+	if pos, _ := od.IndexOf("c"); pos != 0 {
+		t.Errorf("expected c at 0, got %d", pos)
+	}
+	if !slices.Equal(snap.Keys(), []string{"a", "b", "c"}) {
+		t.Errorf("expected snapshot keys [a b c], got %v", snap.Keys())
+	}
+}
+
+func TestSnapshotTTL(t *testing.T) {
+	clock := newFakeClock()
+	od := New[string, int](WithClock(clock.Now))
+	od.SetWithTTL("a", 1, time.Second)
+	od.Set("b", 2)
+
+	snap := od.Snapshot()
+	clock.Advance(time.Second)
+
+	if od.Has("a") {
+		t.Error("a should expire from the dict")
+	}
+	if !snap.Has("a") {
+		t.Error("entries in a snapshot should not expire")
+	}
+	if len(od.s.expiry) != 0 {
+		t.Errorf("expected empty expiry heap, got %d", len(od.s.expiry))
+	}
+}
+
+func TestSnapshotRollback(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	snap := od.Snapshot()
+
+	od.Update(func(tx *Tx[string, int]) error {
+		tx.Set("a", 10)
+		tx.Set("b", 2)
+		return errAbort
+	})
+
+	if val, _ := snap.Get("a"); val != 1 {
+		t.Errorf("expected snapshot a=1, got %d", val)
+	}
+	if val, _ := od.Get("a"); val != 1 || od.Has("b") {
+		t.Error("expected rollback to restore the dict")
+	}
+}
+
+func TestSnapshotClone(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	snap := od.Snapshot()
+
+	c := snap.Clone()
+	c.Set("b", 2)
+	od.Set("c", 3)
+
+	if !slices.Equal(c.Keys(), []string{"a", "b"}) {
+		t.Errorf("expected clone keys [a b], got %v", c.Keys())
+	}
+	if !slices.Equal(snap.Keys(), []string{"a"}) {
+		t.Errorf("expected snapshot keys [a], got %v", snap.Keys())
+	}
+	if !slices.Equal(od.Keys(), []string{"a", "c"}) {
+		t.Errorf("expected dict keys [a c], got %v", od.Keys())
+	}
+}
+
+func TestSnapshotCloneTTL(t *testing.T) {
+	clock := newFakeClock()
+	od := New[string, int](WithTTL(2*time.Second), WithClock(clock.Now))
+	od.SetWithTTL("a", 1, time.Second)
+	od.Set("b", 2)
+
+	c := od.Snapshot().Clone()
+	if !c.Has("a") {
+		t.Fatal("expected a before it expires")
+	}
+	c.Set("c", 3)
+	if !c.Has("a") {
+		t.Error("writing to the clone should not expire a early")
+	}
+
+	clock.Advance(time.Second)
+	if c.Has("a") {
+		t.Error("expected a to expire from the clone")
+	}
+	c.Set("d", 4)
+	clock.Advance(time.Second)
+	if !slices.Equal(c.Keys(), []string{"d"}) {
+		t.Errorf("expected the clone to keep the default TTL, got %v", c.Keys())
+	}
+	checkList(t, c)
+}
+
+func TestClone(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+
+	c := od.Clone()
+	c.Set("a", 10)
+	c.MoveToEnd("a")
+	od.Set("c", 3)
+
+	if !slices.Equal(c.Keys(), []string{"b", "a"}) {
+		t.Errorf("expected clone keys [b a], got %v", c.Keys())
+	}
+	if val, _ := od.Get("a"); val != 1 {
+		t.Errorf("modifying the clone should not affect the original, got a=%d", val)
+	}
+	if c.Has("c") {
+		t.Error("modifying the original should not affect the clone")
+	}
+	checkList(t, od)
+	checkList(t, c)
+}
+
+func TestCloneSettings(t *testing.T) {
+	clock := newFakeClock()
+	var evicted []string
+	od := NewLRU(2, func(key string, _ int) {
+		evicted = append(evicted, key)
+	}, WithTTL(time.Second), WithClock(clock.Now))
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Get("a")
+
+	c := od.Clone()
+	if c.Stats() != (Stats{}) {
+		t.Errorf("expected zeroed stats, got %+v", c.Stats())
+	}
+
+	c.Set("c", 3)
+	if !slices.Equal(evicted, []string{"b"}) {
+		t.Errorf("expected clone to evict b, got %v", evicted)
+	}
+
+	clock.Advance(time.Second)
+	if c.Has("a") {
+		t.Error("expected cloned entries to keep their expiry")
+	}
+	if od.Len() != 0 {
+		t.Errorf("expected original entries to expire, got len=%d", od.Len())
+	}
+}
+
+func TestSnapshotConcurrent(t *testing.T) {
+	od := New[int, int]()
+	for i := range 100 {
+		od.Set(i, i)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range 1000 {
+			od.Set(i%100, -i)
+			od.MoveToStart(i % 100)
+		}
+	}()
+
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				snap := od.Snapshot()
+				count := 0
+				for range snap.All() {
+					count++
+				}
+				if count != 100 || snap.Len() != 100 {
+					t.Errorf("expected 100 entries, got %d", count)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkSnapshot(b *testing.B) {
+	od := New[int, int]()
+	for i := range 100000 {
+		od.Set(i, i)
+	}
+
+	b.ResetTimer()
+	for range b.N {
+		od.Snapshot()
+	}
+}
+
+func BenchmarkSnapshotThenSet(b *testing.B) {
+	od := New[int, int]()
+	for i := range 100000 {
+		od.Set(i, i)
+	}
+
+	b.ResetTimer()
+	for i := range b.N {
+		od.Snapshot()
+		od.Set(i, i)
+	}
+}
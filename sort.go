@@ -0,0 +1,92 @@
+package ordereddict
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Entry is a key-value pair, as passed to the comparison function of
+// SortFunc and SortStableFunc.
+type Entry[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+// SortFunc reorders the dictionary so that its entries are sorted by cmp,
+// which returns a negative number when a should come before b, a positive
+// number when a should come after b and zero otherwise. The sort is not
+// guaranteed to be stable. Only the order changes: lookups stay O(1) and
+// values are untouched.
+//
+// cmp runs while the dictionary is locked, so it must not call back into
+// the dictionary.
+func (o *OrderedDict[K, V]) SortFunc(cmp func(a, b Entry[K, V]) int) {
+	o.sort(cmp, slices.SortFunc[[]*node[K, V]])
+}
+
+// SortStableFunc is like SortFunc, but keeps entries that compare equal in
+// their current order.
+func (o *OrderedDict[K, V]) SortStableFunc(cmp func(a, b Entry[K, V]) int) {
+	o.sort(cmp, slices.SortStableFunc[[]*node[K, V]])
+}
+
+// SortKeys reorders o so that its keys are in ascending order.
+func SortKeys[K cmp.Ordered, V any](o *OrderedDict[K, V]) {
+	o.lock()
+	defer o.unlock()
+	o.expire()
+	sortKeys(o)
+}
+
+// sortKeys implements SortKeys. It must be called with the write lock held.
+func sortKeys[K cmp.Ordered, V any](o *OrderedDict[K, V]) {
+	o.relink(func(nodes []*node[K, V]) {
+		slices.SortFunc(nodes, func(a, b *node[K, V]) int {
+			return cmp.Compare(a.key, b.key)
+		})
+	})
+}
+
+func (o *OrderedDict[K, V]) sort(cmp func(a, b Entry[K, V]) int, sort func([]*node[K, V], func(a, b *node[K, V]) int)) {
+	o.lock()
+	defer o.unlock()
+	o.expire()
+	o.sortNodes(cmp, sort)
+}
+
+// sortNodes reorders the nodes with sort, comparing their entries with cmp.
+// It must be called with the write lock held.
+func (o *OrderedDict[K, V]) sortNodes(cmp func(a, b Entry[K, V]) int, sort func([]*node[K, V], func(a, b *node[K, V]) int)) {
+	o.relink(func(nodes []*node[K, V]) {
+		sort(nodes, func(a, b *node[K, V]) int {
+			return cmp(Entry[K, V]{a.key, a.val}, Entry[K, V]{b.key, b.val})
+		})
+	})
+}
+
+// relink collects the nodes in order, lets reorder permute them and links
+// them back in the new order. The map is not touched. It must be called
+// with the write lock held.
+func (o *OrderedDict[K, V]) relink(reorder func(nodes []*node[K, V])) {
+	if o.s.len < 2 {
+		return
+	}
+	nodes := make([]*node[K, V], 0, o.s.len)
+	for curr := o.s.head.next; curr != o.s.tail; curr = curr.next {
+		nodes = append(nodes, curr)
+	}
+	reorder(nodes)
+
+	o.dropIndex()
+	o.s.dropSnapshot()
+	o.s.stamp++
+	prev := o.s.head
+	for _, n := range nodes {
+		prev.next = n
+		n.prev = prev
+		n.linked = o.s.stamp
+		prev = n
+	}
+	prev.next = o.s.tail
+	o.s.tail.prev = prev
+}
@@ -0,0 +1,128 @@
+package ordereddict
+
+import (
+	"cmp"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestSortKeys(t *testing.T) {
+	od := New[string, int]()
+	for i, key := range []string{"pear", "apple", "fig", "banana"} {
+		od.Set(key, i)
+	}
+
+	SortKeys(od)
+
+	if !slices.Equal(od.Keys(), []string{"apple", "banana", "fig", "pear"}) {
+		t.Errorf("expected sorted keys, got %v", od.Keys())
+	}
+	if val, ok := od.Get("fig"); !ok || val != 2 {
+		t.Errorf("expected fig=2, got %d", val)
+	}
+	checkList(t, od)
+}
+
+func TestSortFunc(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 2)
+	od.Set("b", 3)
+	od.Set("c", 1)
+
+	// Descending by value.
+	od.SortFunc(func(a, b Entry[string, int]) int {
+		return cmp.Compare(b.Value, a.Value)
+	})
+
+	if !slices.Equal(od.Keys(), []string{"b", "a", "c"}) {
+		t.Errorf("expected [b a c], got %v", od.Keys())
+	}
+	if !slices.Equal(od.Values(), []int{3, 2, 1}) {
+		t.Errorf("expected [3 2 1], got %v", od.Values())
+	}
+
+	var back []string
+	for k := range od.KeysBackward() {
+		back = append(back, k)
+	}
+	if !slices.Equal(back, []string{"c", "a", "b"}) {
+		t.Errorf("expected [c a b] backwards, got %v", back)
+	}
+	checkList(t, od)
+}
+
+func TestSortStableFunc(t *testing.T) {
+	od := New[string, int]()
+	for i, key := range []string{"a", "b", "c", "d", "e", "f"} {
+		od.Set(key, i%2)
+	}
+
+	od.SortStableFunc(func(a, b Entry[string, int]) int {
+		return cmp.Compare(a.Value, b.Value)
+	})
+
+	if !slices.Equal(od.Keys(), []string{"a", "c", "e", "b", "d", "f"}) {
+		t.Errorf("expected [a c e b d f], got %v", od.Keys())
+	}
+}
+
+func TestSortWithIndex(t *testing.T) {
+	od := New[int, int]()
+	r := rand.New(rand.NewPCG(1, 2))
+	for _, key := range r.Perm(100) {
+		od.Set(key, key)
+	}
+	od.At(0) // build the position index
+
+	SortKeys(od)
+	checkList(t, od)
+
+	for i := range 100 {
+		if key, _, _ := od.At(i); key != i {
+			t.Fatalf("At(%d): expected %d, got %d", i, i, key)
+		}
+	}
+	od.MoveToStart(50)
+	if pos, _ := od.IndexOf(49); pos != 50 {
+		t.Errorf("expected 49 at 50, got %d", pos)
+	}
+	checkList(t, od)
+}
+
+func TestSortDuringIteration(t *testing.T) {
+	od := New[int, int]()
+	for i := range 5 {
+		od.Set(i, i)
+	}
+
+	var keys []int
+	for k := range od.KeysSeq() {
+		keys = append(keys, k)
+		od.SortFunc(func(a, b Entry[int, int]) int { return cmp.Compare(b.Key, a.Key) })
+	}
+
+	// Sorting moves every entry, so the loop doesn't visit any more of them.
+	if !slices.Equal(keys, []int{0}) {
+		t.Errorf("expected [0], got %v", keys)
+	}
+	if !slices.Equal(od.Keys(), []int{4, 3, 2, 1, 0}) {
+		t.Errorf("expected [4 3 2 1 0], got %v", od.Keys())
+	}
+}
+
+func BenchmarkSortKeys(b *testing.B) {
+	r := rand.New(rand.NewPCG(1, 2))
+	perm := r.Perm(10000)
+	od := New[int, int]()
+
+	for range b.N {
+		b.StopTimer()
+		od.Clear()
+		for _, key := range perm {
+			od.Set(key, key)
+		}
+		b.StartTimer()
+		SortKeys(od)
+	}
+}
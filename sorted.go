@@ -0,0 +1,389 @@
+package ordereddict
+
+import (
+	"fmt"
+	"iter"
+	"math/rand/v2"
+	"strings"
+	"sync"
+)
+
+// sortedMaxLevel bounds the height of the skip list. With a branching
+// factor of 4 it suits dictionaries of up to 4^16 entries.
+const sortedMaxLevel = 16
+
+// SortedOrderedDict is an ordered dictionary whose order is defined by a
+// comparison function on the keys rather than by insertion. Set places new
+// keys in sorted position in O(log n) using a skip list, while lookups go
+// through a map and stay O(1).
+//
+// It offers the read API of OrderedDict plus the range queries Floor,
+// Ceiling and Range. Since the order is fixed by the keys, it has no Move,
+// SetAfter or SetBefore methods.
+type SortedOrderedDict[K comparable, V any] struct {
+	mu    sync.RWMutex
+	cmp   func(a, b K) int
+	data  map[K]*sortedNode[K, V]
+	head  *sortedNode[K, V] // sentinel with sortedMaxLevel links
+	tail  *sortedNode[K, V] // last node, or head if empty
+	level int
+}
+
+type sortedNode[K comparable, V any] struct {
+	key     K
+	val     V
+	next    []*sortedNode[K, V]
+	prev    *sortedNode[K, V]
+	removed bool
+}
+
+// NewSorted creates a new SortedOrderedDict ordered by cmp, which returns a
+// negative number when a sorts before b, a positive number when a sorts
+// after b and zero when they are the same key. cmp must report zero only
+// for keys that are equal.
+func NewSorted[K comparable, V any](cmp func(a, b K) int) *SortedOrderedDict[K, V] {
+	head := &sortedNode[K, V]{next: make([]*sortedNode[K, V], sortedMaxLevel)}
+	return &SortedOrderedDict[K, V]{
+		cmp:   cmp,
+		data:  make(map[K]*sortedNode[K, V]),
+		head:  head,
+		tail:  head,
+		level: 1,
+	}
+}
+
+// search returns the last node whose key sorts before key, or up to and
+// including key if inclusive is set. It returns head if there is none. If
+// update is non-nil, it receives the last such node on every level.
+func (s *SortedOrderedDict[K, V]) search(key K, inclusive bool, update []*sortedNode[K, V]) *sortedNode[K, V] {
+	x := s.head
+	for i := s.level - 1; i >= 0; i-- {
+		for next := x.next[i]; next != nil; next = x.next[i] {
+			c := s.cmp(next.key, key)
+			if c > 0 || c == 0 && !inclusive {
+				break
+			}
+			x = next
+		}
+		if update != nil {
+			update[i] = x
+		}
+	}
+	return x
+}
+
+// Set adds or updates a key-value pair. New keys are placed in sorted
+// position; updating an existing key keeps it where it is.
+func (s *SortedOrderedDict[K, V]) Set(key K, val V) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n, ok := s.data[key]; ok {
+		n.val = val
+		return
+	}
+
+	var update [sortedMaxLevel]*sortedNode[K, V]
+	s.search(key, false, update[:])
+	level := 1
+	for level < sortedMaxLevel && rand.Uint32()&3 == 0 {
+		level++
+	}
+	for ; s.level < level; s.level++ {
+		update[s.level] = s.head
+	}
+
+	n := &sortedNode[K, V]{key: key, val: val, next: make([]*sortedNode[K, V], level)}
+	for i := range level {
+		n.next[i] = update[i].next[i]
+		update[i].next[i] = n
+	}
+	n.prev = update[0]
+	if n.next[0] != nil {
+		n.next[0].prev = n
+	} else {
+		s.tail = n
+	}
+	s.data[key] = n
+}
+
+// remove unlinks a node and drops it from the map. It must be called with
+// the write lock held.
+func (s *SortedOrderedDict[K, V]) remove(n *sortedNode[K, V]) {
+	var update [sortedMaxLevel]*sortedNode[K, V]
+	s.search(n.key, false, update[:])
+	for i := range n.next {
+		update[i].next[i] = n.next[i]
+	}
+	if n.next[0] != nil {
+		n.next[0].prev = n.prev
+	} else {
+		s.tail = n.prev
+	}
+	for s.level > 1 && s.head.next[s.level-1] == nil {
+		s.level--
+	}
+	n.removed = true
+	delete(s.data, n.key)
+}
+
+// Get retrieves a value by key, returns false if key doesn't exist.
+func (s *SortedOrderedDict[K, V]) Get(key K) (V, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n, ok := s.data[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	return n.val, true
+}
+
+// Has checks if a key exists in the dictionary.
+func (s *SortedOrderedDict[K, V]) Has(key K) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.data[key]
+	return ok
+}
+
+// Len returns the number of items in the dictionary.
+func (s *SortedOrderedDict[K, V]) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.data)
+}
+
+// Delete removes a key and returns its value, returns false if key doesn't exist.
+func (s *SortedOrderedDict[K, V]) Delete(key K) (V, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n, ok := s.data[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	s.remove(n)
+	return n.val, true
+}
+
+// Remove deletes a key, returns true if key existed.
+func (s *SortedOrderedDict[K, V]) Remove(key K) bool {
+	_, ok := s.Delete(key)
+	return ok
+}
+
+// PopFirst removes and returns the entry with the smallest key, returns
+// false if the dictionary is empty.
+func (s *SortedOrderedDict[K, V]) PopFirst() (K, V, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pop(s.head.next[0])
+}
+
+// PopLast removes and returns the entry with the largest key, returns false
+// if the dictionary is empty.
+func (s *SortedOrderedDict[K, V]) PopLast() (K, V, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pop(s.entry(s.tail))
+}
+
+func (s *SortedOrderedDict[K, V]) pop(n *sortedNode[K, V]) (K, V, bool) {
+	if n == nil {
+		var zeroK K
+		var zeroV V
+		return zeroK, zeroV, false
+	}
+	s.remove(n)
+	return n.key, n.val, true
+}
+
+// entry maps the head sentinel to nil, so that search results and the tail
+// can be passed to sortedResult and pop.
+func (s *SortedOrderedDict[K, V]) entry(n *sortedNode[K, V]) *sortedNode[K, V] {
+	if n == s.head {
+		return nil
+	}
+	return n
+}
+
+// sortedResult returns the key and value of n, or false if n is nil.
+func sortedResult[K comparable, V any](n *sortedNode[K, V]) (K, V, bool) {
+	if n == nil {
+		var zeroK K
+		var zeroV V
+		return zeroK, zeroV, false
+	}
+	return n.key, n.val, true
+}
+
+// First returns the entry with the smallest key, returns false if the
+// dictionary is empty.
+func (s *SortedOrderedDict[K, V]) First() (K, V, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return sortedResult(s.head.next[0])
+}
+
+// Last returns the entry with the largest key, returns false if the
+// dictionary is empty.
+func (s *SortedOrderedDict[K, V]) Last() (K, V, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return sortedResult(s.entry(s.tail))
+}
+
+// Floor returns the entry with the largest key less than or equal to key,
+// returns false if there is none.
+func (s *SortedOrderedDict[K, V]) Floor(key K) (K, V, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return sortedResult(s.entry(s.search(key, true, nil)))
+}
+
+// Ceiling returns the entry with the smallest key greater than or equal to
+// key, returns false if there is none.
+func (s *SortedOrderedDict[K, V]) Ceiling(key K) (K, V, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return sortedResult(s.search(key, false, nil).next[0])
+}
+
+// Keys returns all keys in sorted order.
+func (s *SortedOrderedDict[K, V]) Keys() []K {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	k := make([]K, 0, len(s.data))
+	for curr := s.head.next[0]; curr != nil; curr = curr.next[0] {
+		k = append(k, curr.key)
+	}
+	return k
+}
+
+// Values returns all values in key order.
+func (s *SortedOrderedDict[K, V]) Values() []V {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v := make([]V, 0, len(s.data))
+	for curr := s.head.next[0]; curr != nil; curr = curr.next[0] {
+		v = append(v, curr.val)
+	}
+	return v
+}
+
+// All returns an iterator over key-value pairs in sorted order.
+//
+// The iterators take the lock only while stepping to the next entry, so
+// the loop body may modify the dictionary. Each step continues from the
+// smallest key greater than the one just visited, so keys added or removed
+// ahead of the loop are seen.
+func (s *SortedOrderedDict[K, V]) All() iter.Seq2[K, V] {
+	return s.ascend(func() *sortedNode[K, V] { return s.head.next[0] }, nil)
+}
+
+// KeysSeq returns an iterator over keys in sorted order, like All.
+func (s *SortedOrderedDict[K, V]) KeysSeq() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for k := range s.All() {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// ValuesSeq returns an iterator over values in key order, like All.
+func (s *SortedOrderedDict[K, V]) ValuesSeq() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for _, v := range s.All() {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Range returns an iterator over the entries with keys from lo up to but
+// not including hi, in sorted order, like All.
+func (s *SortedOrderedDict[K, V]) Range(lo, hi K) iter.Seq2[K, V] {
+	return s.ascend(func() *sortedNode[K, V] {
+		return s.search(lo, false, nil).next[0]
+	}, func(key K) bool {
+		return s.cmp(key, hi) >= 0
+	})
+}
+
+// ascend iterates from the node returned by first until stop reports true
+// for a key or the list ends.
+func (s *SortedOrderedDict[K, V]) ascend(first func() *sortedNode[K, V], stop func(K) bool) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		s.mu.RLock()
+		n := first()
+		for n != nil && (stop == nil || !stop(n.key)) {
+			key, val := n.key, n.val
+			s.mu.RUnlock()
+			if !yield(key, val) {
+				return
+			}
+			s.mu.RLock()
+			if n.removed {
+				n = s.search(key, true, nil).next[0]
+			} else {
+				n = n.next[0]
+			}
+		}
+		s.mu.RUnlock()
+	}
+}
+
+// Backward returns an iterator over key-value pairs in reverse sorted
+// order, like All.
+func (s *SortedOrderedDict[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		s.mu.RLock()
+		n := s.entry(s.tail)
+		for n != nil {
+			key, val := n.key, n.val
+			s.mu.RUnlock()
+			if !yield(key, val) {
+				return
+			}
+			s.mu.RLock()
+			if n.removed {
+				n = s.entry(s.search(key, false, nil))
+			} else {
+				n = s.entry(n.prev)
+			}
+		}
+		s.mu.RUnlock()
+	}
+}
+
+// Clear removes all items from the dictionary.
+func (s *SortedOrderedDict[K, V]) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, n := range s.data {
+		n.removed = true
+	}
+	clear(s.head.next)
+	s.tail = s.head
+	s.level = 1
+	clear(s.data)
+}
+
+// String pretty prints the dictionary.
+func (s *SortedOrderedDict[K, V]) String() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var sb strings.Builder
+	sb.WriteString("SortedOrderedDict[")
+	for curr := s.head.next[0]; curr != nil; curr = curr.next[0] {
+		if curr != s.head.next[0] {
+			sb.WriteString(" ")
+		}
+		fmt.Fprintf(&sb, "%v:%v", curr.key, curr.val)
+	}
+	sb.WriteString("]")
+	return sb.String()
+}
@@ -0,0 +1,278 @@
+package ordereddict
+
+import (
+	"cmp"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSortedSet(t *testing.T) {
+	s := NewSorted[string, int](strings.Compare)
+	s.Set("pear", 1)
+	s.Set("apple", 2)
+	s.Set("fig", 3)
+	s.Set("apple", 20)
+
+	if !slices.Equal(s.Keys(), []string{"apple", "fig", "pear"}) {
+		t.Errorf("expected sorted keys, got %v", s.Keys())
+	}
+	if !slices.Equal(s.Values(), []int{20, 3, 1}) {
+		t.Errorf("expected [20 3 1], got %v", s.Values())
+	}
+	if val, ok := s.Get("apple"); !ok || val != 20 {
+		t.Errorf("expected apple=20, got %d", val)
+	}
+	if !s.Has("fig") || s.Has("kiwi") || s.Len() != 3 {
+		t.Error("unexpected Has or Len result")
+	}
+	if s.String() != "SortedOrderedDict[apple:20 fig:3 pear:1]" {
+		t.Errorf("unexpected String %q", s.String())
+	}
+}
+
+func TestSortedDescending(t *testing.T) {
+	// A leaderboard ordered by descending score, then by name.
+	type score struct {
+		points int
+		name   string
+	}
+	s := NewSorted[score, bool](func(a, b score) int {
+		if c := cmp.Compare(b.points, a.points); c != 0 {
+			return c
+		}
+		return strings.Compare(a.name, b.name)
+	})
+	s.Set(score{10, "bob"}, true)
+	s.Set(score{30, "amy"}, true)
+	s.Set(score{10, "al"}, true)
+
+	var names []string
+	for k := range s.KeysSeq() {
+		names = append(names, k.name)
+	}
+	if !slices.Equal(names, []string{"amy", "al", "bob"}) {
+		t.Errorf("expected [amy al bob], got %v", names)
+	}
+}
+
+func TestSortedFloorCeiling(t *testing.T) {
+	s := NewSorted[int, string](cmp.Compare[int])
+	for _, key := range []int{10, 20, 30} {
+		s.Set(key, "")
+	}
+
+	tests := []struct {
+		key            int
+		floor, ceiling int
+		hasFloor       bool
+		hasCeiling     bool
+	}{
+		{5, 0, 10, false, true},
+		{10, 10, 10, true, true},
+		{15, 10, 20, true, true},
+		{30, 30, 30, true, true},
+		{35, 30, 0, true, false},
+	}
+	for _, tt := range tests {
+		if k, _, ok := s.Floor(tt.key); ok != tt.hasFloor || k != tt.floor {
+			t.Errorf("Floor(%d): expected %d/%v, got %d/%v", tt.key, tt.floor, tt.hasFloor, k, ok)
+		}
+		if k, _, ok := s.Ceiling(tt.key); ok != tt.hasCeiling || k != tt.ceiling {
+			t.Errorf("Ceiling(%d): expected %d/%v, got %d/%v", tt.key, tt.ceiling, tt.hasCeiling, k, ok)
+		}
+	}
+}
+
+func TestSortedRange(t *testing.T) {
+	s := NewSorted[int, int](cmp.Compare[int])
+	for i := range 10 {
+		s.Set(i*10, i)
+	}
+
+	var keys []int
+	for k := range s.Range(15, 50) {
+		keys = append(keys, k)
+	}
+	if !slices.Equal(keys, []int{20, 30, 40}) {
+		t.Errorf("expected [20 30 40], got %v", keys)
+	}
+
+	count := 0
+	for range s.Range(100, 200) {
+		count++
+	}
+	for range s.Range(50, 50) {
+		count++
+	}
+	if count != 0 {
+		t.Errorf("expected empty ranges, got %d entries", count)
+	}
+}
+
+func TestSortedPop(t *testing.T) {
+	s := NewSorted[int, int](cmp.Compare[int])
+	s.Set(2, 20)
+	s.Set(1, 10)
+	s.Set(3, 30)
+
+	if k, v, ok := s.PopFirst(); !ok || k != 1 || v != 10 {
+		t.Errorf("PopFirst: expected 1:10, got %d:%d", k, v)
+	}
+	if k, v, ok := s.PopLast(); !ok || k != 3 || v != 30 {
+		t.Errorf("PopLast: expected 3:30, got %d:%d", k, v)
+	}
+	if k, _, ok := s.Last(); !ok || k != 2 {
+		t.Errorf("Last: expected 2, got %d", k)
+	}
+	if val, ok := s.Delete(2); !ok || val != 20 {
+		t.Errorf("expected to delete 2=20, got %d", val)
+	}
+	if s.Remove(2) {
+		t.Error("expected second removal to fail")
+	}
+	if _, _, ok := s.PopFirst(); ok {
+		t.Error("expected PopFirst on empty dict to fail")
+	}
+	if _, _, ok := s.PopLast(); ok {
+		t.Error("expected PopLast on empty dict to fail")
+	}
+	if _, _, ok := s.First(); ok {
+		t.Error("expected First on empty dict to fail")
+	}
+}
+
+func TestSortedModifyWhileIterating(t *testing.T) {
+	s := NewSorted[int, int](cmp.Compare[int])
+	for i := range 10 {
+		s.Set(i, i)
+	}
+
+	var visited []int
+	for k := range s.All() {
+		visited = append(visited, k)
+		s.Delete(k)
+		s.Delete(k + 1)
+		if k == 4 {
+			s.Set(7, 70)
+			s.Set(100, 100)
+		}
+	}
+	if !slices.Equal(visited, []int{0, 2, 4, 6, 8, 100}) {
+		t.Errorf("expected [0 2 4 6 8 100], got %v", visited)
+	}
+	if s.Len() != 0 {
+		t.Errorf("expected empty dict, got %v", s)
+	}
+
+	for i := range 5 {
+		s.Set(i, i)
+	}
+	var backward []int
+	for k := range s.Backward() {
+		backward = append(backward, k)
+		if k == 3 {
+			s.Clear()
+			s.Set(1, 1)
+		}
+	}
+	if !slices.Equal(backward, []int{4, 3, 1}) {
+		t.Errorf("expected [4 3 1], got %v", backward)
+	}
+}
+
+func TestSortedRandomized(t *testing.T) {
+	r := rand.New(rand.NewPCG(3, 4))
+	s := NewSorted[int, int](cmp.Compare[int])
+	model := make(map[int]int)
+
+	for i := range 5000 {
+		key := r.IntN(500)
+		if r.IntN(3) == 0 {
+			s.Delete(key)
+			delete(model, key)
+		} else {
+			s.Set(key, i)
+			model[key] = i
+		}
+	}
+
+	keys := slices.Sorted(maps.Keys(model))
+	if !slices.Equal(s.Keys(), keys) {
+		t.Fatal("keys diverged from the model")
+	}
+	var backward []int
+	for k, v := range s.Backward() {
+		if model[k] != v {
+			t.Fatalf("key %d: expected %d, got %d", k, model[k], v)
+		}
+		backward = append(backward, k)
+	}
+	slices.Reverse(backward)
+	if !slices.Equal(backward, keys) {
+		t.Fatal("backward order diverged from the model")
+	}
+	for key := range 500 {
+		i, found := slices.BinarySearch(keys, key)
+		k, _, ok := s.Ceiling(key)
+		if ok != (i < len(keys)) || ok && k != keys[i] {
+			t.Fatalf("Ceiling(%d): got %d/%v", key, k, ok)
+		}
+		if !found {
+			i--
+		}
+		k, _, ok = s.Floor(key)
+		if ok != (i >= 0) || ok && k != keys[i] {
+			t.Fatalf("Floor(%d): got %d/%v", key, k, ok)
+		}
+	}
+}
+
+func TestSortedConcurrent(t *testing.T) {
+	s := NewSorted[int, int](cmp.Compare[int])
+	var wg sync.WaitGroup
+
+	for g := range 8 {
+		wg.Add(1)
+		go func(seed uint64) {
+			defer wg.Done()
+			r := rand.New(rand.NewPCG(seed, seed))
+			for range 1000 {
+				key := r.IntN(100)
+				switch r.IntN(4) {
+				case 0, 1:
+					s.Set(key, key)
+				case 2:
+					s.Delete(key)
+				case 3:
+					for k := range s.Range(key, key+10) {
+						s.Get(k)
+					}
+				}
+			}
+		}(uint64(g))
+	}
+	wg.Wait()
+
+	keys := s.Keys()
+	if len(keys) != s.Len() || !slices.IsSorted(keys) {
+		t.Errorf("inconsistent state: %v", keys)
+	}
+}
+
+func BenchmarkSortedSet(b *testing.B) {
+	s := NewSorted[int, int](cmp.Compare[int])
+	r := rand.New(rand.NewPCG(1, 2))
+
+	b.ResetTimer()
+	for range b.N {
+		key := r.IntN(100000)
+		s.Set(key, key)
+		if s.Len() > 50000 {
+			s.PopFirst()
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package ordereddict
+
+import (
+	"container/heap"
+	"time"
+)
+
+// SetWithTTL adds or updates a key-value pair that expires after ttl.
+// A non-positive ttl means the entry never expires. Expired entries are
+// invisible to every method and are reclaimed on the next access or by the
+// janitor enabled with WithJanitor.
+func (o *OrderedDict[K, V]) SetWithTTL(key K, val V, ttl time.Duration) {
+	o.lock()
+	defer o.unlock()
+	o.expire()
+	if ttl > 0 {
+		o.timed.Store(true)
+	}
+	o.set(key, val, ttl)
+}
+
+// Close stops the janitor started by WithJanitor. It is safe to call Close
+// more than once and on dictionaries without a janitor. The dictionary
+// remains usable afterwards; expired entries are still reclaimed lazily.
+func (o *OrderedDict[K, V]) Close() error {
+	o.closed.Do(func() {
+		if o.stop != nil {
+			close(o.stop)
+		}
+	})
+	return nil
+}
+
+// rlock acquires the lock for a read operation and reports whether the write
+// lock was taken. The read lock is used unless an entry is due to expire, in
+// which case it is upgraded to the write lock so that expired entries can be
+// reclaimed before reading.
+func (o *OrderedDict[K, V]) rlock() bool {
+	if !o.unsync {
+		o.mu.RLock()
+	}
+	if !o.timed.Load() || !o.due() {
+		return false
+	}
+	o.runlock(false)
+	o.lock()
+	o.expire()
+	return true
+}
+
+// due reports whether an entry has expired but not yet been reclaimed. It
+// must be called with the lock held.
+func (o *OrderedDict[K, V]) due() bool {
+	return len(o.s.expiry) > 0 && o.s.expiry[0].expires <= o.clock()
+}
+
+// runlock releases the lock taken by rlock.
+func (o *OrderedDict[K, V]) runlock(excl bool) {
+	if excl {
+		o.unlock()
+		return
+	}
+	if !o.unsync {
+		o.mu.RUnlock()
+	}
+}
+
+// expire removes all entries whose expiry time has passed. It must be called
+// with the write lock held.
+func (o *OrderedDict[K, V]) expire() {
+	if len(o.s.expiry) == 0 {
+		return
+	}
+	now := o.clock()
+	if o.s.expiry[0].expires > now {
+		return
+	}
+	for len(o.s.expiry) > 0 && o.s.expiry[0].expires <= now {
+		o.removeNode(o.s.expiry[0])
+	}
+}
+
+// setExpiry sets a node to expire after ttl, or never if ttl is not positive.
+func (o *OrderedDict[K, V]) setExpiry(n *node[K, V], ttl time.Duration) {
+	if ttl <= 0 {
+		o.expireAt(n, 0)
+		return
+	}
+	o.expireAt(n, o.clock()+int64(ttl))
+}
+
+// expireAt sets a node to expire at the given Unix nanosecond time, or never
+// if expires is 0, keeping the expiry heap in sync.
+func (o *OrderedDict[K, V]) expireAt(n *node[K, V], expires int64) {
+	if n.expires != expires {
+		o.s.touch(n.key)
+	}
+	switch {
+	case expires == 0:
+		if n.expires != 0 {
+			heap.Remove(&o.s.expiry, n.hidx)
+			n.expires = 0
+		}
+	case n.expires != 0:
+		n.expires = expires
+		heap.Fix(&o.s.expiry, n.hidx)
+	default:
+		n.expires = expires
+		heap.Push(&o.s.expiry, n)
+	}
+}
+
+// clock returns the current time in Unix nanoseconds.
+func (o *OrderedDict[K, V]) clock() int64 {
+	if o.now != nil {
+		return o.now().UnixNano()
+	}
+	return time.Now().UnixNano()
+}
+
+// janitor periodically reclaims expired entries until stop is closed.
+func (o *OrderedDict[K, V]) janitor(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			o.lock()
+			o.expire()
+			o.unlock()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// expiryHeap is a min-heap of nodes ordered by expiry time.
+type expiryHeap[K comparable, V any] []*node[K, V]
+
+func (h expiryHeap[K, V]) Len() int { return len(h) }
+
+func (h expiryHeap[K, V]) Less(i, j int) bool { return h[i].expires < h[j].expires }
+
+func (h expiryHeap[K, V]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].hidx = i
+	h[j].hidx = j
+}
+
+func (h *expiryHeap[K, V]) Push(x any) {
+	n := x.(*node[K, V])
+	n.hidx = len(*h)
+	*h = append(*h, n)
+}
+
+func (h *expiryHeap[K, V]) Pop() any {
+	old := *h
+	n := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return n
+}
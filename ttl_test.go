@@ -0,0 +1,358 @@
+package ordereddict
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock for deterministic expiry tests.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(1700000000, 0)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestSetWithTTL(t *testing.T) {
+	clock := newFakeClock()
+	od := New[string, int](WithClock(clock.Now))
+
+	od.SetWithTTL("short", 1, time.Second)
+	od.SetWithTTL("long", 2, time.Minute)
+	od.Set("forever", 3)
+
+	if od.Len() != 3 {
+		t.Fatalf("expected len=3, got %d", od.Len())
+	}
+
+	clock.Advance(2 * time.Second)
+
+	if _, ok := od.Get("short"); ok {
+		t.Error("expired key should not be returned by Get")
+	}
+	if od.Has("short") {
+		t.Error("expired key should not be reported by Has")
+	}
+	if od.Len() != 2 {
+		t.Errorf("expected len=2, got %d", od.Len())
+	}
+
+	keys := od.Keys()
+	expected := []string{"long", "forever"}
+	if len(keys) != len(expected) {
+		t.Fatalf("expected %d keys, got %d", len(expected), len(keys))
+	}
+	for i, key := range keys {
+		if key != expected[i] {
+			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
+		}
+	}
+
+	clock.Advance(time.Hour)
+
+	if od.Len() != 1 {
+		t.Errorf("expected len=1, got %d", od.Len())
+	}
+	if val, ok := od.Get("forever"); !ok || val != 3 {
+		t.Error("entry without TTL should never expire")
+	}
+}
+
+func TestSetWithTTLExpiresAtDeadline(t *testing.T) {
+	clock := newFakeClock()
+	od := New[string, int](WithClock(clock.Now))
+	od.SetWithTTL("a", 1, time.Second)
+
+	clock.Advance(time.Second - time.Nanosecond)
+	if !od.Has("a") {
+		t.Error("entry should still be live before its deadline")
+	}
+	clock.Advance(time.Nanosecond)
+	if od.Has("a") {
+		t.Error("entry should expire at its deadline")
+	}
+}
+
+func TestSetWithTTLNonPositive(t *testing.T) {
+	clock := newFakeClock()
+	od := New[string, int](WithClock(clock.Now))
+	od.SetWithTTL("a", 1, time.Second)
+	od.SetWithTTL("a", 2, 0)
+
+	clock.Advance(time.Hour)
+	if val, ok := od.Get("a"); !ok || val != 2 {
+		t.Error("non-positive TTL should clear the expiry")
+	}
+}
+
+func TestTTLValuesAndAll(t *testing.T) {
+	clock := newFakeClock()
+	od := New[string, int](WithClock(clock.Now))
+	od.SetWithTTL("a", 1, time.Second)
+	od.Set("b", 2)
+	od.SetWithTTL("c", 3, time.Second)
+
+	clock.Advance(time.Second)
+
+	values := od.Values()
+	if len(values) != 1 || values[0] != 2 {
+		t.Errorf("expected [2], got %v", values)
+	}
+
+	count := 0
+	for key := range od.All() {
+		if key != "b" {
+			t.Errorf("unexpected key %s", key)
+		}
+		count++
+	}
+	if count != 1 {
+		t.Errorf("expected 1 iteration, got %d", count)
+	}
+}
+
+func TestWithTTLDefault(t *testing.T) {
+	clock := newFakeClock()
+	od := New[string, int](WithTTL(time.Minute), WithClock(clock.Now))
+
+	od.Set("a", 1)
+	clock.Advance(30 * time.Second)
+	od.Set("b", 2)
+	od.SetWithTTL("c", 3, time.Hour)
+
+	clock.Advance(30 * time.Second)
+	if od.Has("a") {
+		t.Error("a should expire after the default TTL")
+	}
+	if !od.Has("b") || !od.Has("c") {
+		t.Error("b and c should still be live")
+	}
+
+	clock.Advance(30 * time.Second)
+	if od.Has("b") {
+		t.Error("b should expire after the default TTL")
+	}
+	if !od.Has("c") {
+		t.Error("explicit TTL should override the default")
+	}
+}
+
+func TestSetRefreshesTTL(t *testing.T) {
+	clock := newFakeClock()
+	od := New[string, int](WithTTL(time.Minute), WithClock(clock.Now))
+
+	od.Set("a", 1)
+	clock.Advance(45 * time.Second)
+	od.Set("a", 2)
+	clock.Advance(45 * time.Second)
+
+	if val, ok := od.Get("a"); !ok || val != 2 {
+		t.Error("updating a key should refresh its TTL")
+	}
+}
+
+func TestExpiredKeyReinsertedAtEnd(t *testing.T) {
+	clock := newFakeClock()
+	od := New[string, int](WithClock(clock.Now))
+	od.SetWithTTL("a", 1, time.Second)
+	od.Set("b", 2)
+
+	clock.Advance(time.Second)
+	od.Set("a", 3)
+
+	keys := od.Keys()
+	expected := []string{"b", "a"}
+	for i, key := range keys {
+		if key != expected[i] {
+			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
+		}
+	}
+}
+
+func TestTTLDeleteAndClear(t *testing.T) {
+	clock := newFakeClock()
+	od := New[string, int](WithClock(clock.Now))
+	od.SetWithTTL("a", 1, time.Second)
+	od.SetWithTTL("b", 2, time.Second)
+
+	if _, ok := od.Delete("a"); !ok {
+		t.Error("expected delete of live key to succeed")
+	}
+	if len(od.s.expiry) != 1 {
+		t.Errorf("expected 1 entry in expiry heap, got %d", len(od.s.expiry))
+	}
+
+	clock.Advance(time.Second)
+	if _, ok := od.Delete("b"); ok {
+		t.Error("expected delete of expired key to fail")
+	}
+
+	od.SetWithTTL("c", 3, time.Second)
+	od.Clear()
+	if len(od.s.expiry) != 0 {
+		t.Errorf("expected empty expiry heap after clear, got %d", len(od.s.expiry))
+	}
+}
+
+func TestTTLWithLRU(t *testing.T) {
+	clock := newFakeClock()
+	var evicted []string
+	od := NewLRU(2, func(key string, _ int) {
+		evicted = append(evicted, key)
+	}, WithClock(clock.Now))
+
+	od.SetWithTTL("a", 1, time.Second)
+	od.Set("b", 2)
+	clock.Advance(time.Second)
+	od.Set("c", 3)
+
+	if len(evicted) != 0 {
+		t.Errorf("expired entries should be reclaimed before evicting, got %v", evicted)
+	}
+	if od.Len() != 2 {
+		t.Errorf("expected len=2, got %d", od.Len())
+	}
+}
+
+func TestTTLMergeRefreshes(t *testing.T) {
+	clock := newFakeClock()
+	od := New[string, int](WithTTL(2*time.Second), WithClock(clock.Now))
+	od.Set("a", 1)
+	od.Set("b", 2)
+	clock.Advance(time.Second)
+
+	other := New[string, int]()
+	other.Set("a", 10)
+	od.MergeWith(other, func(_ string, old, new int) int { return old + new })
+	clock.Advance(time.Second)
+
+	if !slices.Equal(od.Keys(), []string{"a"}) {
+		t.Errorf("expected merging to refresh a's TTL, got %v", od.Keys())
+	}
+	if val, _ := od.Get("a"); val != 11 {
+		t.Errorf("expected a=11, got %d", val)
+	}
+}
+
+func TestTTLReadsShareLock(t *testing.T) {
+	clock := newFakeClock()
+	od := New[string, int](WithClock(clock.Now))
+	od.SetWithTTL("a", 1, time.Second)
+	od.Set("b", 2)
+
+	// With nothing due to expire, reads only need the read lock, so they
+	// proceed while another reader holds it.
+	od.mu.RLock()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		od.Get("a")
+		od.Has("b")
+		od.Len()
+		od.Keys()
+		for range od.All() {
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("reads waited for the write lock")
+	}
+	od.mu.RUnlock()
+
+	clock.Advance(time.Second)
+	if od.Has("a") || od.Len() != 1 {
+		t.Error("expected a to expire")
+	}
+	if len(od.s.expiry) != 0 {
+		t.Error("expected the read to reclaim the expired entry")
+	}
+}
+
+func TestJanitor(t *testing.T) {
+	clock := newFakeClock()
+	od := New[string, int](WithClock(clock.Now), WithJanitor(time.Millisecond))
+	defer od.Close()
+
+	for i := range 10 {
+		od.SetWithTTL(string(rune('a'+i)), i, time.Second)
+	}
+	clock.Advance(time.Second)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		od.mu.RLock()
+		n := od.s.len
+		od.mu.RUnlock()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("janitor did not reclaim expired entries, len=%d", n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestClose(t *testing.T) {
+	od := New[string, int](WithJanitor(time.Millisecond))
+	if err := od.Close(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := od.Close(); err != nil {
+		t.Errorf("unexpected error on second close: %v", err)
+	}
+
+	od.Set("a", 1)
+	if !od.Has("a") {
+		t.Error("dict should remain usable after Close")
+	}
+
+	if err := New[string, int]().Close(); err != nil {
+		t.Errorf("unexpected error closing dict without janitor: %v", err)
+	}
+}
+
+func TestTTLConcurrent(t *testing.T) {
+	clock := newFakeClock()
+	od := New[int, int](WithTTL(time.Second), WithClock(clock.Now), WithJanitor(time.Millisecond))
+	defer od.Close()
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			for j := range 100 {
+				key := id*100 + j
+				od.Set(key, key)
+				od.Get(key)
+				if j%10 == 0 {
+					clock.Advance(100 * time.Millisecond)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	clock.Advance(time.Second)
+	if od.Len() != 0 {
+		t.Errorf("expected all entries to expire, got len=%d", od.Len())
+	}
+}
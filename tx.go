@@ -0,0 +1,626 @@
+package ordereddict
+
+import (
+	"cmp"
+	"iter"
+	"reflect"
+	"slices"
+	"time"
+)
+
+// Tx is a transaction on an OrderedDict, passed to the function given to
+// Update. Its methods behave like the OrderedDict methods of the same name,
+// but their changes are only kept if the transaction commits. A Tx must not
+// be used after the function returns.
+type Tx[K comparable, V any] struct {
+	o    *OrderedDict[K, V]
+	undo []undoEntry[K, V]
+	done bool
+}
+
+// undoEntry records the state of a node before a transaction changed it.
+type undoEntry[K comparable, V any] struct {
+	n       *node[K, V]
+	prev    *node[K, V]
+	val     V
+	expires int64
+
+	// added is set if the transaction inserted n, removed if it deleted n
+	// and renamed if it changed n's key from oldKey.
+	added   bool
+	removed bool
+	renamed bool
+	oldKey  K
+
+	// order is the whole order before a reordering such as a sort. Such
+	// entries have no node.
+	order []*node[K, V]
+}
+
+// Update runs fn in a transaction and returns its error. If fn returns nil
+// the changes made through tx become visible all at once; if it returns an
+// error or panics they are discarded, including any changes to the order.
+//
+// The dictionary is locked for the duration of fn, so fn must not call
+// methods on the dictionary directly. In LRU mode, entries beyond the
+// maximum length are evicted when the transaction commits.
+func (o *OrderedDict[K, V]) Update(fn func(tx *Tx[K, V]) error) (err error) {
+	o.lock()
+	defer o.unlock()
+	o.expire()
+
+	tx := &Tx[K, V]{o: o}
+	o.inTx = true
+	committed := false
+	defer func() {
+		tx.done = true
+		o.inTx = false
+		if !committed {
+			tx.rollback()
+			return
+		}
+		o.evict()
+	}()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+// rollback undoes the recorded changes in reverse order. Each entry only
+// touched its own node, so undoing in reverse restores every neighbour an
+// entry refers to before that entry is undone.
+func (tx *Tx[K, V]) rollback() {
+	o := tx.o
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		e := tx.undo[i]
+		switch {
+		case e.order != nil:
+			o.relink(func(nodes []*node[K, V]) { copy(nodes, e.order) })
+		case e.renamed:
+			delete(o.s.data, e.n.key)
+			o.s.touch(e.n.key)
+			e.n.key = e.oldKey
+			o.s.data[e.oldKey] = e.n
+			o.s.touch(e.oldKey)
+		case e.added:
+			o.removeNode(e.n)
+		case e.removed:
+			e.n.removed = false
+			o.linkAfter(e.n, e.prev)
+			o.s.data[e.n.key] = e.n
+			o.s.len++
+			o.expireAt(e.n, e.expires)
+		default:
+			e.n.val = e.val
+			o.s.touch(e.n.key)
+			o.expireAt(e.n, e.expires)
+			if e.n.prev != e.prev {
+				o.unlinkNode(e.n)
+				o.linkAfter(e.n, e.prev)
+			}
+		}
+	}
+	tx.undo = nil
+}
+
+// check panics if the transaction has finished.
+func (tx *Tx[K, V]) check() {
+	if tx.done {
+		panic("ordereddict: Tx used after Update returned")
+	}
+}
+
+// save records the current state of an existing node.
+func (tx *Tx[K, V]) save(n *node[K, V], removed bool) {
+	tx.undo = append(tx.undo, undoEntry[K, V]{
+		n:       n,
+		prev:    n.prev,
+		val:     n.val,
+		expires: n.expires,
+		removed: removed,
+	})
+}
+
+// saveOrder records the current order before a reordering. Reorderings are
+// no-ops with fewer than two entries, so nothing is recorded then.
+func (tx *Tx[K, V]) saveOrder() {
+	o := tx.o
+	if o.s.len < 2 {
+		return
+	}
+	order := make([]*node[K, V], 0, o.s.len)
+	for curr := o.s.head.next; curr != o.s.tail; curr = curr.next {
+		order = append(order, curr)
+	}
+	tx.undo = append(tx.undo, undoEntry[K, V]{order: order})
+}
+
+// put records the state of key before fn stores it, then runs fn.
+func (tx *Tx[K, V]) put(key K, fn func()) {
+	o := tx.o
+	if existing, ok := o.s.data[key]; ok {
+		tx.save(existing, false)
+		fn()
+		return
+	}
+	fn()
+	tx.undo = append(tx.undo, undoEntry[K, V]{n: o.s.data[key], added: true})
+}
+
+// Set adds or updates a key-value pair.
+func (tx *Tx[K, V]) Set(key K, val V) {
+	tx.check()
+	tx.put(key, func() { tx.o.set(key, val, tx.o.ttl) })
+}
+
+// SetWithTTL adds or updates a key-value pair that expires after ttl.
+func (tx *Tx[K, V]) SetWithTTL(key K, val V, ttl time.Duration) {
+	tx.check()
+	if ttl > 0 {
+		tx.o.timed.Store(true)
+	}
+	tx.put(key, func() { tx.o.set(key, val, ttl) })
+}
+
+// SetAfter adds or updates a key-value pair and places it directly after
+// another key, returns false if the after key doesn't exist.
+func (tx *Tx[K, V]) SetAfter(after K, key K, val V) bool {
+	tx.check()
+	afterNode, ok := tx.o.s.data[after]
+	if !ok {
+		return false
+	}
+	tx.put(key, func() { tx.o.setAfter(afterNode, key, val) })
+	return true
+}
+
+// SetBefore adds or updates a key-value pair and places it directly before
+// another key, returns false if the before key doesn't exist.
+func (tx *Tx[K, V]) SetBefore(before K, key K, val V) bool {
+	tx.check()
+	beforeNode, ok := tx.o.s.data[before]
+	if !ok {
+		return false
+	}
+	tx.put(key, func() { tx.o.setAfter(beforeNode.prev, key, val) })
+	return true
+}
+
+// SetFirst adds or updates a key-value pair and places it at the start.
+func (tx *Tx[K, V]) SetFirst(key K, val V) {
+	tx.check()
+	tx.put(key, func() { tx.o.setAfter(tx.o.s.head, key, val) })
+}
+
+// SetMany adds or updates every key-value pair produced by seq, in order.
+// The sequence is read in full first, so it may iterate over tx.
+func (tx *Tx[K, V]) SetMany(seq iter.Seq2[K, V]) {
+	tx.check()
+	var entries []Entry[K, V]
+	for key, val := range seq {
+		entries = append(entries, Entry[K, V]{key, val})
+	}
+	for _, e := range entries {
+		tx.Set(e.Key, e.Value)
+	}
+}
+
+// Merge merges another OrderedDict into the dictionary, as
+// OrderedDict.Merge does. other is read under its read lock while the
+// dictionary is locked, so other must not be the dictionary of a
+// transaction running at the same time.
+func (tx *Tx[K, V]) Merge(other *OrderedDict[K, V]) {
+	tx.merge(other, nil, false)
+}
+
+// MergeWith is like Merge, but for keys present in both dictionaries it
+// stores fn(key, old, new) instead of the value from other. fn must not use
+// tx.
+func (tx *Tx[K, V]) MergeWith(other *OrderedDict[K, V], fn func(key K, old, new V) V) {
+	tx.merge(other, fn, false)
+}
+
+// MergeToEnd is like Merge, but keys that already exist are moved to the
+// end.
+func (tx *Tx[K, V]) MergeToEnd(other *OrderedDict[K, V]) {
+	tx.merge(other, nil, true)
+}
+
+func (tx *Tx[K, V]) merge(other *OrderedDict[K, V], fn func(key K, old, new V) V, toEnd bool) {
+	tx.check()
+	if other == nil || other == tx.o {
+		return
+	}
+	for _, e := range other.entries() {
+		tx.put(e.Key, func() { tx.o.mergeEntry(e.Key, e.Value, fn, toEnd) })
+	}
+}
+
+// Replace replaces the contents of the dictionary with the entries of src,
+// in src's order, as OrderedDict.Replace does. As with Merge, src is read
+// under its read lock. A nil src empties the dictionary.
+func (tx *Tx[K, V]) Replace(src *OrderedDict[K, V]) {
+	tx.check()
+	if src == tx.o {
+		return
+	}
+	var entries []Entry[K, V]
+	if src != nil {
+		entries = src.entries()
+	}
+	tx.replace(entries)
+}
+
+func (tx *Tx[K, V]) replace(entries []Entry[K, V]) {
+	tx.Clear()
+	for _, e := range entries {
+		tx.Set(e.Key, e.Value)
+	}
+}
+
+// UnmarshalJSON decodes a JSON object into the dictionary, replacing its
+// contents, as OrderedDict.UnmarshalJSON does. On error the dictionary is
+// left unchanged.
+func (tx *Tx[K, V]) UnmarshalJSON(data []byte) error {
+	tx.check()
+	tmp, err := unmarshalObject[K, V](data, reflect.TypeOf(tx.o))
+	if tmp == nil {
+		return err
+	}
+	tx.replace(tmp.entries())
+	return nil
+}
+
+// GetOrSet returns the existing value for key if present. Otherwise it sets
+// key to val and returns val. Like Get, it never promotes an existing key
+// in LRU mode.
+func (tx *Tx[K, V]) GetOrSet(key K, val V) (actual V, loaded bool) {
+	tx.check()
+	if existing, ok := tx.o.s.data[key]; ok {
+		return existing.val, true
+	}
+	tx.Set(key, val)
+	return val, false
+}
+
+// SetIfAbsent sets key to val only if key doesn't exist, returns true if the
+// value was set.
+func (tx *Tx[K, V]) SetIfAbsent(key K, val V) bool {
+	_, loaded := tx.GetOrSet(key, val)
+	return !loaded
+}
+
+// CompareAndSwapTx sets key to newVal if its current value equals old,
+// returns true if the value was swapped. It is the counterpart of
+// CompareAndSwap inside a transaction, where CompareAndSwap would
+// deadlock.
+func CompareAndSwapTx[K comparable, V comparable](tx *Tx[K, V], key K, old, newVal V) bool {
+	tx.check()
+	existing, ok := tx.o.s.data[key]
+	if !ok || existing.val != old {
+		return false
+	}
+	tx.Set(key, newVal)
+	return true
+}
+
+// Compute updates the entry for key, as OrderedDict.Compute does. fn must
+// not use tx.
+func (tx *Tx[K, V]) Compute(key K, fn func(old V, exists bool) (newVal V, keep bool)) (V, bool) {
+	tx.check()
+	var old V
+	existing, exists := tx.o.s.data[key]
+	if exists {
+		old = existing.val
+	}
+
+	newVal, keep := fn(old, exists)
+	if !keep {
+		if exists {
+			tx.Delete(key)
+		}
+		var zero V
+		return zero, false
+	}
+	tx.Set(key, newVal)
+	return newVal, true
+}
+
+// Get retrieves a value by key, returns false if key doesn't exist. Unlike
+// OrderedDict.Get it never promotes the key in LRU mode.
+func (tx *Tx[K, V]) Get(key K) (V, bool) {
+	tx.check()
+	node, ok := tx.o.s.data[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	return node.val, true
+}
+
+// Has checks if a key exists.
+func (tx *Tx[K, V]) Has(key K) bool {
+	tx.check()
+	_, ok := tx.o.s.data[key]
+	return ok
+}
+
+// Len returns the number of items, including uncommitted changes.
+func (tx *Tx[K, V]) Len() int {
+	tx.check()
+	return tx.o.s.len
+}
+
+// Keys returns all keys in order, including uncommitted changes.
+func (tx *Tx[K, V]) Keys() []K {
+	tx.check()
+	o := tx.o
+	keys := make([]K, 0, o.s.len)
+	for curr := o.s.head.next; curr != o.s.tail; curr = curr.next {
+		keys = append(keys, curr.key)
+	}
+	return keys
+}
+
+// Values returns all values in order, including uncommitted changes.
+func (tx *Tx[K, V]) Values() []V {
+	tx.check()
+	o := tx.o
+	vals := make([]V, 0, o.s.len)
+	for curr := o.s.head.next; curr != o.s.tail; curr = curr.next {
+		vals = append(vals, curr.val)
+	}
+	return vals
+}
+
+// All returns an iterator over key-value pairs in order, including
+// uncommitted changes. Like OrderedDict.All it ranges over the entries as
+// they were when iteration started, so the loop body may use tx.
+func (tx *Tx[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		tx.check()
+		o := tx.o
+		entries := make([]Entry[K, V], 0, o.s.len)
+		for curr := o.s.head.next; curr != o.s.tail; curr = curr.next {
+			entries = append(entries, Entry[K, V]{curr.key, curr.val})
+		}
+		for _, e := range entries {
+			if !yield(e.Key, e.Value) {
+				return
+			}
+		}
+	}
+}
+
+// Delete removes a key and returns its value, returns false if key doesn't exist.
+func (tx *Tx[K, V]) Delete(key K) (V, bool) {
+	tx.check()
+	node, ok := tx.o.s.data[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	tx.save(node, true)
+	tx.o.removeNode(node)
+	return node.val, true
+}
+
+// Remove deletes a key, returns true if key existed.
+func (tx *Tx[K, V]) Remove(key K) bool {
+	_, ok := tx.Delete(key)
+	return ok
+}
+
+// PopFirst removes and returns the first key-value pair, returns false if
+// the dictionary is empty.
+func (tx *Tx[K, V]) PopFirst() (K, V, bool) {
+	tx.check()
+	return tx.pop(tx.o.s.head.next)
+}
+
+// PopLast removes and returns the last key-value pair, returns false if the
+// dictionary is empty.
+func (tx *Tx[K, V]) PopLast() (K, V, bool) {
+	tx.check()
+	return tx.pop(tx.o.s.tail.prev)
+}
+
+// PopItem removes and returns the last key-value pair if last is true, or
+// the first one otherwise, returns false if the dictionary is empty.
+func (tx *Tx[K, V]) PopItem(last bool) (K, V, bool) {
+	if last {
+		return tx.PopLast()
+	}
+	return tx.PopFirst()
+}
+
+// DeleteMany removes the given keys and returns the number of keys that
+// existed.
+func (tx *Tx[K, V]) DeleteMany(keys ...K) int {
+	deleted := 0
+	for _, key := range keys {
+		if tx.Remove(key) {
+			deleted++
+		}
+	}
+	return deleted
+}
+
+// Clear removes all items from the dictionary.
+func (tx *Tx[K, V]) Clear() {
+	tx.check()
+	for tx.o.s.len > 0 {
+		tx.pop(tx.o.s.head.next)
+	}
+}
+
+func (tx *Tx[K, V]) pop(n *node[K, V]) (K, V, bool) {
+	if tx.o.s.len == 0 {
+		var zeroK K
+		var zeroV V
+		return zeroK, zeroV, false
+	}
+	tx.save(n, true)
+	tx.o.removeNode(n)
+	return n.key, n.val, true
+}
+
+// MoveToEnd moves a key to the end of the order, returns false if key doesn't exist.
+func (tx *Tx[K, V]) MoveToEnd(key K) bool {
+	tx.check()
+	return tx.move(key, tx.o.linkToEnd)
+}
+
+// MoveToStart moves a key to the start of the order, returns false if key doesn't exist.
+func (tx *Tx[K, V]) MoveToStart(key K) bool {
+	tx.check()
+	return tx.move(key, tx.o.linkToStart)
+}
+
+// MoveAfter moves a key after another key, returns false if either key doesn't
+// exist or both are the same key.
+func (tx *Tx[K, V]) MoveAfter(key K, after K) bool {
+	tx.check()
+	afterNode, ok := tx.o.s.data[after]
+	if !ok || afterNode.key == key {
+		return false
+	}
+	return tx.move(key, func(n *node[K, V]) { tx.o.linkAfter(n, afterNode) })
+}
+
+// MoveBefore moves a key before another key, returns false if either key
+// doesn't exist or both are the same key.
+func (tx *Tx[K, V]) MoveBefore(key K, before K) bool {
+	tx.check()
+	beforeNode, ok := tx.o.s.data[before]
+	if !ok || beforeNode.key == key {
+		return false
+	}
+	return tx.move(key, func(n *node[K, V]) { tx.o.linkAfter(n, beforeNode.prev) })
+}
+
+// move unlinks key and relinks it with link, returns false if key doesn't
+// exist.
+func (tx *Tx[K, V]) move(key K, link func(n *node[K, V])) bool {
+	n, ok := tx.o.s.data[key]
+	if !ok {
+		return false
+	}
+	tx.moveNode(n, link)
+	return true
+}
+
+// moveNode records the state of n, then unlinks it and relinks it with
+// link.
+func (tx *Tx[K, V]) moveNode(n *node[K, V], link func(n *node[K, V])) {
+	tx.save(n, false)
+	tx.o.unlinkNode(n)
+	link(n)
+}
+
+// Swap exchanges the positions of two keys, returns false if either key
+// doesn't exist or both are the same key.
+func (tx *Tx[K, V]) Swap(a K, b K) bool {
+	tx.check()
+	o := tx.o
+	nodeA, ok := o.s.data[a]
+	if !ok {
+		return false
+	}
+	nodeB, ok := o.s.data[b]
+	if !ok || nodeA == nodeB {
+		return false
+	}
+	if nodeB.next == nodeA {
+		nodeA, nodeB = nodeB, nodeA
+	}
+	if nodeA.next == nodeB {
+		tx.moveNode(nodeA, func(n *node[K, V]) { o.linkAfter(n, nodeB) })
+		return true
+	}
+	prevA, prevB := nodeA.prev, nodeB.prev
+	tx.moveNode(nodeA, func(n *node[K, V]) { o.linkAfter(n, prevB) })
+	tx.moveNode(nodeB, func(n *node[K, V]) { o.linkAfter(n, prevA) })
+	return true
+}
+
+// Rotate moves the first n entries to the end of the order, or the last -n
+// entries to the start if n is negative, as OrderedDict.Rotate does.
+func (tx *Tx[K, V]) Rotate(n int) {
+	tx.check()
+	o := tx.o
+	if o.s.len == 0 {
+		return
+	}
+	n %= o.s.len
+	if n < 0 {
+		n += o.s.len
+	}
+	if n <= o.s.len/2 {
+		for range n {
+			tx.moveNode(o.s.head.next, o.linkToEnd)
+		}
+		return
+	}
+	for range o.s.len - n {
+		tx.moveNode(o.s.tail.prev, o.linkToStart)
+	}
+}
+
+// Reverse reverses the order of the entries.
+func (tx *Tx[K, V]) Reverse() {
+	tx.check()
+	tx.saveOrder()
+	tx.o.relink(slices.Reverse[[]*node[K, V]])
+}
+
+// SortFunc reorders the entries by cmp, as OrderedDict.SortFunc does.
+func (tx *Tx[K, V]) SortFunc(cmp func(a, b Entry[K, V]) int) {
+	tx.check()
+	tx.saveOrder()
+	tx.o.sortNodes(cmp, slices.SortFunc[[]*node[K, V]])
+}
+
+// SortStableFunc reorders the entries by cmp, keeping equal entries in
+// their current order.
+func (tx *Tx[K, V]) SortStableFunc(cmp func(a, b Entry[K, V]) int) {
+	tx.check()
+	tx.saveOrder()
+	tx.o.sortNodes(cmp, slices.SortStableFunc[[]*node[K, V]])
+}
+
+// SortKeysTx reorders the entries of tx so that their keys are in
+// ascending order. It is the counterpart of SortKeys inside a transaction,
+// where SortKeys would deadlock.
+func SortKeysTx[K cmp.Ordered, V any](tx *Tx[K, V]) {
+	tx.check()
+	tx.saveOrder()
+	sortKeys(tx.o)
+}
+
+// ReorderTo rearranges the entries to follow keys, as OrderedDict.ReorderTo
+// does.
+func (tx *Tx[K, V]) ReorderTo(keys []K, opts ...ReorderOption) error {
+	tx.check()
+	var cfg reorderOptions
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	tx.saveOrder()
+	return tx.o.reorderTo(keys, cfg)
+}
+
+// RenameKey changes the key of an entry from old to new, keeping its
+// position and value, as OrderedDict.RenameKey does.
+func (tx *Tx[K, V]) RenameKey(old, new K) error {
+	tx.check()
+	n := tx.o.s.data[old]
+	if err := tx.o.renameKey(old, new); err != nil || old == new {
+		return err
+	}
+	tx.undo = append(tx.undo, undoEntry[K, V]{n: n, renamed: true, oldKey: old})
+	return nil
+}
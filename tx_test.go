@@ -0,0 +1,477 @@
+package ordereddict
+
+import (
+	"errors"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+var errAbort = errors.New("abort")
+
+func TestUpdateCommit(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+
+	err := od.Update(func(tx *Tx[string, int]) error {
+		tx.Set("c", 3)
+		tx.Set("a", 10)
+		tx.Delete("b")
+		tx.MoveToStart("c")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkList(t, od)
+
+	keys := od.Keys()
+	expected := []string{"c", "a"}
+	if !slices.Equal(keys, expected) {
+		t.Errorf("expected %v, got %v", expected, keys)
+	}
+	if val, _ := od.Get("a"); val != 10 {
+		t.Errorf("expected a=10, got %d", val)
+	}
+}
+
+func TestUpdateRollback(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
+	od.At(0) // build the index so that rollback must maintain it
+
+	err := od.Update(func(tx *Tx[string, int]) error {
+		tx.Set("d", 4)
+		tx.Set("a", 10)
+		tx.Delete("b")
+		tx.MoveToEnd("a")
+		tx.SetFirst("e", 5)
+		tx.MoveAfter("c", "d")
+		tx.SetBefore("c", "b", 20)
+		tx.PopFirst()
+		tx.PopLast()
+		if tx.Len() != 3 {
+			t.Errorf("expected tx to see len=3, got %d", tx.Len())
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("expected errAbort, got %v", err)
+	}
+	checkList(t, od)
+
+	keys := od.Keys()
+	expected := []string{"a", "b", "c"}
+	if !slices.Equal(keys, expected) {
+		t.Errorf("expected %v, got %v", expected, keys)
+	}
+	for i, key := range expected {
+		if val, _ := od.Get(key); val != i+1 {
+			t.Errorf("expected %s=%d, got %d", key, i+1, val)
+		}
+	}
+}
+
+func TestUpdateRollbackOnPanic(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected panic to propagate")
+			}
+		}()
+		od.Update(func(tx *Tx[string, int]) error {
+			tx.Set("b", 2)
+			panic("boom")
+		})
+	}()
+
+	if od.Has("b") {
+		t.Error("changes should be discarded on panic")
+	}
+	od.Set("c", 3) // the lock must have been released
+	if od.Len() != 2 {
+		t.Errorf("expected len=2, got %d", od.Len())
+	}
+}
+
+func TestUpdateRollbackTTL(t *testing.T) {
+	clock := newFakeClock()
+	od := New[string, int](WithClock(clock.Now))
+	od.SetWithTTL("a", 1, time.Second)
+	od.Set("b", 2)
+
+	od.Update(func(tx *Tx[string, int]) error {
+		tx.Set("a", 10) // clears the expiry
+		tx.SetWithTTL("b", 20, time.Second)
+		tx.Delete("b")
+		tx.SetWithTTL("c", 3, time.Second)
+		return errAbort
+	})
+
+	if len(od.s.expiry) != 1 {
+		t.Fatalf("expected 1 entry in expiry heap, got %d", len(od.s.expiry))
+	}
+	clock.Advance(time.Second)
+	if od.Has("a") {
+		t.Error("a should keep its original expiry after rollback")
+	}
+	if val, ok := od.Get("b"); !ok || val != 2 {
+		t.Error("b should be restored without an expiry")
+	}
+}
+
+func TestUpdateLRUEvictsOnCommit(t *testing.T) {
+	var evicted []string
+	od := NewLRU(2, func(key string, _ int) {
+		evicted = append(evicted, key)
+	})
+	od.Set("a", 1)
+	od.Set("b", 2)
+
+	od.Update(func(tx *Tx[string, int]) error {
+		tx.Set("c", 3)
+		tx.Set("d", 4)
+		if tx.Len() != 4 {
+			t.Errorf("expected eviction to be deferred, got len=%d", tx.Len())
+		}
+		return errAbort
+	})
+	if len(evicted) != 0 {
+		t.Errorf("rolled back transaction should not evict, got %v", evicted)
+	}
+
+	od.Update(func(tx *Tx[string, int]) error {
+		tx.Set("c", 3)
+		tx.Set("d", 4)
+		return nil
+	})
+	if !slices.Equal(evicted, []string{"a", "b"}) {
+		t.Errorf("expected a and b to be evicted, got %v", evicted)
+	}
+	if !slices.Equal(od.Keys(), []string{"c", "d"}) {
+		t.Errorf("expected [c d], got %v", od.Keys())
+	}
+}
+
+func TestUpdateRejectedMoves(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+
+	od.Update(func(tx *Tx[string, int]) error {
+		if tx.MoveAfter("a", "a") || tx.MoveBefore("a", "a") {
+			t.Error("expected self-referential moves to fail")
+		}
+		if tx.MoveToEnd("missing") || tx.SetAfter("missing", "c", 3) {
+			t.Error("expected moves of missing keys to fail")
+		}
+		return nil
+	})
+	checkList(t, od)
+}
+
+func TestTxUseAfterUpdate(t *testing.T) {
+	od := New[string, int]()
+	var leaked *Tx[string, int]
+	od.Update(func(tx *Tx[string, int]) error {
+		leaked = tx
+		return nil
+	})
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected using a finished Tx to panic")
+		}
+	}()
+	leaked.Set("a", 1)
+}
+
+func TestUpdateRollbackRandomized(t *testing.T) {
+	r := rand.New(rand.NewPCG(3, 4))
+	od := New[int, int]()
+	for i := range 50 {
+		od.Set(i, i)
+	}
+	od.At(0)
+
+	for range 200 {
+		before := od.Keys()
+		values := od.Values()
+		od.Update(func(tx *Tx[int, int]) error {
+			for range 20 {
+				key := r.IntN(80)
+				switch r.IntN(8) {
+				case 0:
+					tx.Set(key, -key)
+				case 1:
+					tx.Delete(key)
+				case 2:
+					tx.MoveToEnd(key)
+				case 3:
+					tx.MoveToStart(key)
+				case 4:
+					tx.MoveAfter(key, r.IntN(80))
+				case 5:
+					tx.MoveBefore(key, r.IntN(80))
+				case 6:
+					tx.SetAfter(r.IntN(80), key, -key)
+				case 7:
+					tx.PopFirst()
+				}
+			}
+			return errAbort
+		})
+		checkList(t, od)
+		if !slices.Equal(od.Keys(), before) || !slices.Equal(od.Values(), values) {
+			t.Fatal("rollback did not restore the dictionary")
+		}
+	}
+}
+
+func TestUpdateCommitFullAPI(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 1)
+	od.Set("b", 2)
+	od.Set("c", 3)
+
+	err := od.Update(func(tx *Tx[string, int]) error {
+		tx.SetMany(maps.All(map[string]int{"d": 4}))
+		if val, loaded := tx.GetOrSet("a", 10); !loaded || val != 1 {
+			t.Errorf("expected a=1 to be loaded, got %d", val)
+		}
+		if !tx.SetIfAbsent("e", 5) || tx.SetIfAbsent("e", 50) {
+			t.Error("unexpected SetIfAbsent result")
+		}
+		tx.Compute("b", func(old int, _ bool) (int, bool) { return old * 10, true })
+		tx.Compute("c", func(int, bool) (int, bool) { return 0, false })
+		if k, _, ok := tx.PopItem(true); !ok || k != "e" {
+			t.Errorf("expected to pop e, got %s", k)
+		}
+		tx.Swap("a", "d") // d b a
+		tx.Rotate(1)      // b a d
+		tx.Reverse()      // d a b
+		tx.RenameKey("d", "z")
+		if err := tx.ReorderTo([]string{"b"}); err != nil {
+			t.Errorf("unexpected ReorderTo error: %v", err)
+		}
+		if !slices.Equal(tx.Keys(), []string{"b", "z", "a"}) || !slices.Equal(tx.Values(), []int{20, 4, 1}) {
+			t.Errorf("unexpected tx contents %v %v", tx.Keys(), tx.Values())
+		}
+		tx.SortStableFunc(func(a, b Entry[string, int]) int { return a.Value - b.Value })
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkList(t, od)
+	if !slices.Equal(od.Keys(), []string{"a", "z", "b"}) {
+		t.Errorf("expected [a z b], got %v", od.Keys())
+	}
+
+	od.Update(func(tx *Tx[string, int]) error {
+		var keys []string
+		for k := range tx.All() {
+			keys = append(keys, k)
+			tx.Delete(k)
+		}
+		if !slices.Equal(keys, []string{"a", "z", "b"}) {
+			t.Errorf("expected All to visit [a z b], got %v", keys)
+		}
+		tx.Set("x", 1)
+		tx.Set("y", 2)
+		if tx.DeleteMany("x", "missing") != 1 {
+			t.Error("expected DeleteMany to delete x")
+		}
+		tx.Clear()
+		if tx.Len() != 0 {
+			t.Errorf("expected empty tx after Clear, got %v", tx.Keys())
+		}
+		return nil
+	})
+	if od.Len() != 0 {
+		t.Errorf("expected empty dict, got %v", od)
+	}
+}
+
+func TestUpdateMergeAndReplace(t *testing.T) {
+	od := New[string, int]()
+	od.Set("b", 2)
+	od.Set("a", 1)
+	other := New[string, int]()
+	other.Set("a", 10)
+	other.Set("c", 30)
+
+	err := od.Update(func(tx *Tx[string, int]) error {
+		tx.MergeToEnd(other) // b a c
+		tx.MergeWith(other, func(_ string, old, new int) int { return old + new })
+		tx.Merge(tx.o) // merging with itself does nothing
+		if !CompareAndSwapTx(tx, "b", 2, 20) || CompareAndSwapTx(tx, "b", 2, 30) {
+			t.Error("unexpected CompareAndSwapTx result")
+		}
+		SortKeysTx(tx)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkList(t, od)
+	if !slices.Equal(od.Keys(), []string{"a", "b", "c"}) || !slices.Equal(od.Values(), []int{20, 20, 60}) {
+		t.Errorf("expected a:20 b:20 c:60, got %v", od)
+	}
+
+	err = od.Update(func(tx *Tx[string, int]) error {
+		tx.Replace(other)
+		if !slices.Equal(tx.Keys(), []string{"a", "c"}) {
+			t.Errorf("expected [a c] after Replace, got %v", tx.Keys())
+		}
+		if err := tx.UnmarshalJSON([]byte(`{"z": 1, "y": 2}`)); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.UnmarshalJSON([]byte(`[1]`)); err == nil {
+			t.Error("expected an error for a JSON array")
+		}
+		if !slices.Equal(tx.Keys(), []string{"z", "y"}) {
+			t.Errorf("expected [z y] after UnmarshalJSON, got %v", tx.Keys())
+		}
+		return errAbort
+	})
+	if err != errAbort {
+		t.Fatalf("expected errAbort, got %v", err)
+	}
+	checkList(t, od)
+	if !slices.Equal(od.Keys(), []string{"a", "b", "c"}) || !slices.Equal(od.Values(), []int{20, 20, 60}) {
+		t.Errorf("expected rollback to restore a:20 b:20 c:60, got %v", od)
+	}
+
+	od.Update(func(tx *Tx[string, int]) error {
+		tx.Replace(nil)
+		return nil
+	})
+	if od.Len() != 0 {
+		t.Errorf("expected Replace(nil) to empty the dict, got %v", od)
+	}
+}
+
+func TestUpdateRollbackReorderings(t *testing.T) {
+	r := rand.New(rand.NewPCG(5, 6))
+	clock := newFakeClock()
+	od := New[int, int](WithClock(clock.Now))
+	for i := range 30 {
+		od.SetWithTTL(i, i, time.Duration(i%3)*time.Second)
+	}
+	od.At(0)
+
+	other := New[int, int]()
+	for i := 25; i < 35; i++ {
+		other.Set(i, -i)
+	}
+
+	for range 200 {
+		before := od.Keys()
+		values := od.Values()
+		od.Update(func(tx *Tx[int, int]) error {
+			for range 20 {
+				key := r.IntN(40)
+				switch r.IntN(18) {
+				case 0:
+					tx.Set(key, -key)
+				case 1:
+					tx.Delete(key)
+				case 2:
+					tx.MoveAfter(key, r.IntN(40))
+				case 3:
+					tx.Swap(key, r.IntN(40))
+				case 4:
+					tx.Rotate(r.IntN(20) - 10)
+				case 5:
+					tx.Reverse()
+				case 6:
+					tx.SortFunc(func(a, b Entry[int, int]) int { return b.Value - a.Value })
+				case 7:
+					tx.ReorderTo([]int{key, (key + 1) % 40})
+				case 8:
+					tx.RenameKey(key, r.IntN(40))
+				case 9:
+					tx.Compute(key, func(old int, exists bool) (int, bool) { return old + 1, !exists })
+				case 10:
+					tx.PopItem(r.IntN(2) == 0)
+				case 11:
+					if r.IntN(10) == 0 {
+						tx.Clear()
+					}
+				case 12:
+					tx.MergeToEnd(other)
+				case 13:
+					tx.MergeWith(other, func(_ int, old, new int) int { return old + new })
+				case 14:
+					if r.IntN(10) == 0 {
+						tx.Replace(other)
+					}
+				case 15:
+					tx.UnmarshalJSON([]byte(`{"1": 1, "50": 50}`))
+				case 16:
+					SortKeysTx(tx)
+				case 17:
+					CompareAndSwapTx(tx, key, key, key*2)
+				}
+			}
+			return errAbort
+		})
+		checkList(t, od)
+		if !slices.Equal(od.Keys(), before) || !slices.Equal(od.Values(), values) {
+			t.Fatal("rollback did not restore the dictionary")
+		}
+	}
+
+	clock.Advance(time.Second)
+	if od.Len() != 20 {
+		t.Errorf("expected entries to keep their expiry, got len=%d", od.Len())
+	}
+}
+
+func TestUpdateConcurrent(t *testing.T) {
+	od := New[string, int]()
+	od.Set("a", 100)
+	od.Set("b", 0)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				// Transfer one unit from a to b; the total is invariant.
+				od.Update(func(tx *Tx[string, int]) error {
+					a, _ := tx.Get("a")
+					b, _ := tx.Get("b")
+					if a == 0 {
+						return errAbort
+					}
+					tx.Set("a", a-1)
+					tx.Set("b", b+1)
+					return nil
+				})
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				values := od.Values()
+				if values[0]+values[1] != 100 {
+					t.Errorf("observed partial transaction: %v", values)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,294 @@
+package ordereddict
+
+import (
+	"iter"
+	"time"
+)
+
+// Unsync is an ordered dictionary for use by a single goroutine. It shares
+// OrderedDict's implementation but never locks, which saves the cost of the
+// mutex on every call. It is not safe for concurrent use; hand it to Wrap to
+// share it between goroutines.
+//
+// Unsync has the methods of OrderedDict except Close, as it never runs a
+// janitor. The package-level functions CompareAndSwap and SortKeys only
+// accept an *OrderedDict; use Compute and SortFunc instead.
+type Unsync[K comparable, V any] struct {
+	o *OrderedDict[K, V]
+}
+
+// NewUnsync creates a new Unsync. It accepts the same options as New, except
+// that WithJanitor is ignored since the janitor would run concurrently.
+func NewUnsync[K comparable, V any](opts ...Option) *Unsync[K, V] {
+	o := &OrderedDict[K, V]{s: newStore[K, V](0), unsync: true}
+	o.configure(opts)
+	return &Unsync[K, V]{o: o}
+}
+
+// NewUnsyncLRU creates an Unsync that behaves as a least-recently-used cache
+// holding at most maxLen entries, like NewLRU. WithJanitor is ignored, as
+// for NewUnsync. NewUnsyncLRU panics if maxLen is not positive.
+func NewUnsyncLRU[K comparable, V any](maxLen int, onEvict func(key K, val V), opts ...Option) *Unsync[K, V] {
+	if maxLen <= 0 {
+		panic("ordereddict: NewUnsyncLRU requires a positive maxLen")
+	}
+	o := &OrderedDict[K, V]{
+		s:       newStore[K, V](maxLen),
+		unsync:  true,
+		maxLen:  maxLen,
+		onEvict: onEvict,
+	}
+	o.configure(opts)
+	return &Unsync[K, V]{o: o}
+}
+
+// Wrap returns a synchronized OrderedDict holding u's entries, in O(1). The
+// entries are handed over rather than copied, so u must not be used
+// afterwards.
+func Wrap[K comparable, V any](u *Unsync[K, V]) *OrderedDict[K, V] {
+	o := u.o
+	o.unsync = false
+	u.o = nil
+	return o
+}
+
+// lock acquires the write lock, unless the dictionary is unsynchronized.
+func (o *OrderedDict[K, V]) lock() {
+	if !o.unsync {
+		o.mu.Lock()
+	}
+}
+
+// unlock releases the write lock taken by lock.
+func (o *OrderedDict[K, V]) unlock() {
+	if !o.unsync {
+		o.mu.Unlock()
+	}
+}
+
+// Set adds or updates a key-value pair.
+func (u *Unsync[K, V]) Set(key K, val V) { u.o.Set(key, val) }
+
+// SetWithTTL adds or updates a key-value pair that expires after ttl.
+func (u *Unsync[K, V]) SetWithTTL(key K, val V, ttl time.Duration) { u.o.SetWithTTL(key, val, ttl) }
+
+// SetAfter adds or updates a key-value pair and places it directly after
+// another key, returns false if the after key doesn't exist.
+func (u *Unsync[K, V]) SetAfter(after K, key K, val V) bool { return u.o.SetAfter(after, key, val) }
+
+// SetBefore adds or updates a key-value pair and places it directly before
+// another key, returns false if the before key doesn't exist.
+func (u *Unsync[K, V]) SetBefore(before K, key K, val V) bool {
+	return u.o.SetBefore(before, key, val)
+}
+
+// SetFirst adds or updates a key-value pair and places it at the start of
+// the order.
+func (u *Unsync[K, V]) SetFirst(key K, val V) { u.o.SetFirst(key, val) }
+
+// SetMany adds or updates every key-value pair produced by seq, in order.
+func (u *Unsync[K, V]) SetMany(seq iter.Seq2[K, V]) { u.o.SetMany(seq) }
+
+// Merge merges other into u, as OrderedDict.Merge does.
+func (u *Unsync[K, V]) Merge(other *Unsync[K, V]) { u.o.Merge(other.o) }
+
+// MergeWith merges other into u, storing fn(key, old, new) for keys present
+// in both, as OrderedDict.MergeWith does.
+func (u *Unsync[K, V]) MergeWith(other *Unsync[K, V], fn func(key K, old, new V) V) {
+	u.o.MergeWith(other.o, fn)
+}
+
+// MergeToEnd merges other into u, moving keys that already exist to the
+// end, as OrderedDict.MergeToEnd does.
+func (u *Unsync[K, V]) MergeToEnd(other *Unsync[K, V]) { u.o.MergeToEnd(other.o) }
+
+// Get retrieves a value by key, returns false if key doesn't exist.
+func (u *Unsync[K, V]) Get(key K) (V, bool) { return u.o.Get(key) }
+
+// GetMany retrieves the given keys, returning their values and found flags
+// in the order the keys were given.
+func (u *Unsync[K, V]) GetMany(keys ...K) ([]V, []bool) { return u.o.GetMany(keys...) }
+
+// Peek retrieves a value by key without marking it as recently used or
+// updating the cache counters.
+func (u *Unsync[K, V]) Peek(key K) (V, bool) { return u.o.Peek(key) }
+
+// Stats returns the hit, miss and eviction counters of an LRU Unsync.
+func (u *Unsync[K, V]) Stats() Stats { return u.o.Stats() }
+
+// GetOrSet returns the existing value for key if present. Otherwise it sets
+// key to val and returns val.
+func (u *Unsync[K, V]) GetOrSet(key K, val V) (actual V, loaded bool) { return u.o.GetOrSet(key, val) }
+
+// SetIfAbsent sets key to val only if key doesn't exist, returns true if the
+// value was set.
+func (u *Unsync[K, V]) SetIfAbsent(key K, val V) bool { return u.o.SetIfAbsent(key, val) }
+
+// Compute updates the entry for key, as OrderedDict.Compute does.
+func (u *Unsync[K, V]) Compute(key K, fn func(old V, exists bool) (newVal V, keep bool)) (V, bool) {
+	return u.o.Compute(key, fn)
+}
+
+// Delete removes a key and returns its value, returns false if key doesn't exist.
+func (u *Unsync[K, V]) Delete(key K) (V, bool) { return u.o.Delete(key) }
+
+// Remove deletes a key, returns true if key existed.
+func (u *Unsync[K, V]) Remove(key K) bool { return u.o.Remove(key) }
+
+// DeleteMany removes the given keys and returns the number that existed.
+func (u *Unsync[K, V]) DeleteMany(keys ...K) int { return u.o.DeleteMany(keys...) }
+
+// PopFirst removes and returns the first key-value pair, returns false if
+// the dictionary is empty.
+func (u *Unsync[K, V]) PopFirst() (K, V, bool) { return u.o.PopFirst() }
+
+// PopLast removes and returns the last key-value pair, returns false if the
+// dictionary is empty.
+func (u *Unsync[K, V]) PopLast() (K, V, bool) { return u.o.PopLast() }
+
+// PopItem removes and returns the last key-value pair if last is true, or
+// the first one otherwise, returns false if the dictionary is empty.
+func (u *Unsync[K, V]) PopItem(last bool) (K, V, bool) { return u.o.PopItem(last) }
+
+// First returns the first key-value pair, returns false if the dictionary
+// is empty.
+func (u *Unsync[K, V]) First() (K, V, bool) { return u.o.First() }
+
+// Last returns the last key-value pair, returns false if the dictionary is
+// empty.
+func (u *Unsync[K, V]) Last() (K, V, bool) { return u.o.Last() }
+
+// Next returns the key-value pair that follows key in the order, returns
+// false if key doesn't exist or is the last key.
+func (u *Unsync[K, V]) Next(key K) (K, V, bool) { return u.o.Next(key) }
+
+// Prev returns the key-value pair that precedes key in the order, returns
+// false if key doesn't exist or is the first key.
+func (u *Unsync[K, V]) Prev(key K) (K, V, bool) { return u.o.Prev(key) }
+
+// Len returns the number of items in the dictionary.
+func (u *Unsync[K, V]) Len() int { return u.o.Len() }
+
+// Has checks if a key exists in the dictionary.
+func (u *Unsync[K, V]) Has(key K) bool { return u.o.Has(key) }
+
+// Keys returns all keys in order.
+func (u *Unsync[K, V]) Keys() []K { return u.o.Keys() }
+
+// Values returns all values in order.
+func (u *Unsync[K, V]) Values() []V { return u.o.Values() }
+
+// All returns an iterator over key-value pairs in order.
+func (u *Unsync[K, V]) All() iter.Seq2[K, V] { return u.o.All() }
+
+// KeysSeq returns an iterator over keys in order.
+func (u *Unsync[K, V]) KeysSeq() iter.Seq[K] { return u.o.KeysSeq() }
+
+// ValuesSeq returns an iterator over values in order.
+func (u *Unsync[K, V]) ValuesSeq() iter.Seq[V] { return u.o.ValuesSeq() }
+
+// Backward returns an iterator over key-value pairs in reverse order.
+func (u *Unsync[K, V]) Backward() iter.Seq2[K, V] { return u.o.Backward() }
+
+// KeysBackward returns an iterator over keys in reverse order.
+func (u *Unsync[K, V]) KeysBackward() iter.Seq[K] { return u.o.KeysBackward() }
+
+// ValuesBackward returns an iterator over values in reverse order.
+func (u *Unsync[K, V]) ValuesBackward() iter.Seq[V] { return u.o.ValuesBackward() }
+
+// At returns the entry at position i in the order, returns false if i is
+// out of range.
+func (u *Unsync[K, V]) At(i int) (K, V, bool) { return u.o.At(i) }
+
+// IndexOf returns the position of a key in the order, returns false if key
+// doesn't exist.
+func (u *Unsync[K, V]) IndexOf(key K) (int, bool) { return u.o.IndexOf(key) }
+
+// Slice returns a new Unsync holding the entries at positions [start, end),
+// as OrderedDict.Slice does.
+func (u *Unsync[K, V]) Slice(start, end int) *Unsync[K, V] {
+	o := u.o.Slice(start, end)
+	o.unsync = true
+	return &Unsync[K, V]{o: o}
+}
+
+// MoveToEnd moves a key to the end of the order, returns false if key doesn't exist.
+func (u *Unsync[K, V]) MoveToEnd(key K) bool { return u.o.MoveToEnd(key) }
+
+// MoveToStart moves a key to the start of the order, returns false if key doesn't exist.
+func (u *Unsync[K, V]) MoveToStart(key K) bool { return u.o.MoveToStart(key) }
+
+// MoveAfter moves a key after another key, returns false if either key doesn't
+// exist or both are the same key.
+func (u *Unsync[K, V]) MoveAfter(key K, after K) bool { return u.o.MoveAfter(key, after) }
+
+// MoveBefore moves a key before another key, returns false if either key
+// doesn't exist or both are the same key.
+func (u *Unsync[K, V]) MoveBefore(key K, before K) bool { return u.o.MoveBefore(key, before) }
+
+// Swap exchanges the positions of two keys, returns false if either key
+// doesn't exist or both are the same key.
+func (u *Unsync[K, V]) Swap(a K, b K) bool { return u.o.Swap(a, b) }
+
+// Rotate moves the first n entries to the end of the order, or the last -n
+// entries to the start if n is negative.
+func (u *Unsync[K, V]) Rotate(n int) { u.o.Rotate(n) }
+
+// Reverse reverses the order of the entries in place.
+func (u *Unsync[K, V]) Reverse() { u.o.Reverse() }
+
+// SortFunc reorders the entries by cmp, as OrderedDict.SortFunc does.
+func (u *Unsync[K, V]) SortFunc(cmp func(a, b Entry[K, V]) int) { u.o.SortFunc(cmp) }
+
+// SortStableFunc reorders the entries by cmp, keeping equal entries in
+// their current order.
+func (u *Unsync[K, V]) SortStableFunc(cmp func(a, b Entry[K, V]) int) { u.o.SortStableFunc(cmp) }
+
+// ReorderTo rearranges the entries to follow keys, as OrderedDict.ReorderTo
+// does.
+func (u *Unsync[K, V]) ReorderTo(keys []K, opts ...ReorderOption) error {
+	return u.o.ReorderTo(keys, opts...)
+}
+
+// RenameKey changes the key of an entry from old to new, keeping its
+// position and value.
+func (u *Unsync[K, V]) RenameKey(old, new K) error { return u.o.RenameKey(old, new) }
+
+// Clear removes all items from the dictionary.
+func (u *Unsync[K, V]) Clear() { u.o.Clear() }
+
+// Update runs fn in a transaction, as OrderedDict.Update does.
+func (u *Unsync[K, V]) Update(fn func(tx *Tx[K, V]) error) error { return u.o.Update(fn) }
+
+// Replace replaces the contents of u with the entries of src, in src's
+// order, as OrderedDict.Replace does. A nil src empties u.
+func (u *Unsync[K, V]) Replace(src *Unsync[K, V]) {
+	if src == nil {
+		u.o.Clear()
+		return
+	}
+	u.o.Replace(src.o)
+}
+
+// Immutable returns an immutable copy of the current contents.
+func (u *Unsync[K, V]) Immutable() *ImmutableOrderedDict[K, V] { return u.o.Immutable() }
+
+// Snapshot returns a read-only view of the current contents. Unlike u, the
+// snapshot may be shared between goroutines.
+func (u *Unsync[K, V]) Snapshot() *Snapshot[K, V] { return u.o.Snapshot() }
+
+// Clone returns a copy of u with the same LRU and expiry settings.
+func (u *Unsync[K, V]) Clone() *Unsync[K, V] {
+	o := u.o.Clone()
+	o.unsync = true
+	return &Unsync[K, V]{o: o}
+}
+
+// String pretty prints the dictionary.
+func (u *Unsync[K, V]) String() string { return u.o.String() }
+
+// MarshalJSON encodes the dictionary as a JSON object in order.
+func (u *Unsync[K, V]) MarshalJSON() ([]byte, error) { return u.o.MarshalJSON() }
+
+// UnmarshalJSON replaces the contents with a JSON object in document order.
+func (u *Unsync[K, V]) UnmarshalJSON(data []byte) error { return u.o.UnmarshalJSON(data) }
@@ -0,0 +1,282 @@
+package ordereddict
+
+import (
+	"encoding/json"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUnsync(t *testing.T) {
+	u := NewUnsync[string, int]()
+	u.Set("a", 1)
+	u.Set("b", 2)
+	u.SetFirst("z", 0)
+	u.MoveToEnd("a")
+
+	if !slices.Equal(u.Keys(), []string{"z", "b", "a"}) {
+		t.Errorf("expected [z b a], got %v", u.Keys())
+	}
+	if val, ok := u.Get("b"); !ok || val != 2 {
+		t.Errorf("expected b=2, got %d", val)
+	}
+	if pos, _ := u.IndexOf("a"); pos != 2 {
+		t.Errorf("expected a at 2, got %d", pos)
+	}
+	checkList(t, u.o)
+
+	count := 0
+	for range u.All() {
+		count++
+	}
+	if count != 3 || u.Len() != 3 {
+		t.Errorf("expected 3 entries, got %d", count)
+	}
+
+	b, err := json.Marshal(u)
+	if err != nil || string(b) != `{"z":0,"b":2,"a":1}` {
+		t.Errorf("unexpected JSON %s (%v)", b, err)
+	}
+}
+
+func TestUnsyncTTL(t *testing.T) {
+	clock := newFakeClock()
+	u := NewUnsync[string, int](WithClock(clock.Now), WithJanitor(time.Millisecond))
+	if u.o.stop != nil {
+		t.Error("expected WithJanitor to be ignored")
+	}
+
+	u.SetWithTTL("a", 1, time.Second)
+	u.Set("b", 2)
+	clock.Advance(time.Second)
+	if u.Has("a") || u.Len() != 1 {
+		t.Error("expected a to expire")
+	}
+}
+
+func TestUnsyncMerge(t *testing.T) {
+	u := NewUnsync[string, int]()
+	u.Set("a", 1)
+	u.Set("b", 2)
+	other := NewUnsync[string, int]()
+	other.Set("a", 10)
+	other.Set("c", 3)
+
+	u.MergeWith(other, func(_ string, old, new int) int { return old + new })
+	if !slices.Equal(u.Values(), []int{11, 2, 3}) {
+		t.Errorf("expected [11 2 3], got %v", u.Values())
+	}
+	u.MergeToEnd(other)
+	if !slices.Equal(u.Keys(), []string{"b", "a", "c"}) {
+		t.Errorf("expected [b a c], got %v", u.Keys())
+	}
+	checkList(t, u.o)
+}
+
+func TestUnsyncOptionsNotModified(t *testing.T) {
+	opts := make([]Option, 1, 2)
+	opts[0] = WithTTL(time.Second)
+	NewUnsync[string, int](opts...)
+	if opts[:2][1] != nil {
+		t.Error("NewUnsync should not write into the caller's options")
+	}
+}
+
+func TestUnsyncLRU(t *testing.T) {
+	var evicted []string
+	u := NewUnsyncLRU(2, func(key string, _ int) {
+		evicted = append(evicted, key)
+	})
+	u.Set("a", 1)
+	u.Set("b", 2)
+	u.Get("a")
+	u.Set("c", 3)
+
+	if !slices.Equal(u.Keys(), []string{"a", "c"}) || !slices.Equal(evicted, []string{"b"}) {
+		t.Errorf("expected b to be evicted, got %v", u.Keys())
+	}
+	if val, _ := u.Peek("c"); val != 3 {
+		t.Errorf("expected c=3, got %d", val)
+	}
+	if stats := u.Stats(); stats.Hits != 1 || stats.Evictions != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestUnsyncCopies(t *testing.T) {
+	u := NewUnsync[string, int]()
+	u.Set("a", 1)
+	u.Set("b", 2)
+	u.Set("c", 3)
+
+	snap := u.Snapshot()
+	imm := u.Immutable()
+	c := u.Clone()
+	part := u.Slice(1, 3)
+	if !c.o.unsync || !part.o.unsync {
+		t.Error("expected copies of an Unsync to be unsynchronized")
+	}
+	c.Set("d", 4)
+	part.Delete("b")
+	u.Set("a", 10)
+
+	if !slices.Equal(snap.Values(), []int{1, 2, 3}) {
+		t.Errorf("expected snapshot [1 2 3], got %v", snap.Values())
+	}
+	if !slices.Equal(c.Keys(), []string{"a", "b", "c", "d"}) {
+		t.Errorf("expected clone [a b c d], got %v", c.Keys())
+	}
+	if !slices.Equal(part.Keys(), []string{"c"}) {
+		t.Errorf("expected slice [c], got %v", part.Keys())
+	}
+	if !slices.Equal(imm.Values(), []int{1, 2, 3}) {
+		t.Errorf("expected immutable copy [1 2 3], got %v", imm.Values())
+	}
+	checkList(t, u.o)
+	checkList(t, c.o)
+
+	u.Replace(c)
+	c.Delete("a")
+	if !slices.Equal(u.Keys(), []string{"a", "b", "c", "d"}) || !u.o.unsync {
+		t.Errorf("expected Replace to copy [a b c d], got %v", u.Keys())
+	}
+	u.Replace(nil)
+	if u.Len() != 0 {
+		t.Errorf("expected Replace(nil) to empty u, got %v", u.Keys())
+	}
+}
+
+func TestUnsyncUpdate(t *testing.T) {
+	u := NewUnsync[string, int]()
+	u.Set("a", 1)
+	u.Set("b", 2)
+
+	err := u.Update(func(tx *Tx[string, int]) error {
+		tx.Set("c", 3)
+		tx.Delete("a")
+		return errAbort
+	})
+	if err != errAbort || !slices.Equal(u.Keys(), []string{"a", "b"}) {
+		t.Errorf("expected rollback, got %v (%v)", u.Keys(), err)
+	}
+
+	var keys []string
+	for k := range u.KeysBackward() {
+		keys = append(keys, k)
+	}
+	if !slices.Equal(keys, []string{"b", "a"}) {
+		t.Errorf("expected [b a], got %v", keys)
+	}
+	if k, _, ok := u.PopItem(true); !ok || k != "b" {
+		t.Errorf("expected to pop b, got %s", k)
+	}
+	vals, found := u.GetMany("a", "b")
+	if !slices.Equal(vals, []int{1, 0}) || !slices.Equal(found, []bool{true, false}) {
+		t.Errorf("unexpected GetMany result %v %v", vals, found)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	u := NewUnsync[int, int]()
+	for i := range 10 {
+		u.Set(i, i)
+	}
+
+	od := Wrap(u)
+	if u.o != nil {
+		t.Error("expected Wrap to take over the entries")
+	}
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			for j := range 100 {
+				od.Set(id*100+j, j)
+				od.Get(j)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if od.Len() != 1000 {
+		t.Errorf("expected len=1000, got %d", od.Len())
+	}
+	checkList(t, od)
+}
+
+// The benchmarks below measure the locking overhead Unsync avoids.
+
+func BenchmarkOrderedDictGetSingle(b *testing.B) {
+	od := New[int, int]()
+	for i := range 1000 {
+		od.Set(i, i)
+	}
+
+	b.ResetTimer()
+	for i := range b.N {
+		od.Get(i % 1000)
+	}
+}
+
+func BenchmarkUnsyncGet(b *testing.B) {
+	u := NewUnsync[int, int]()
+	for i := range 1000 {
+		u.Set(i, i)
+	}
+
+	b.ResetTimer()
+	for i := range b.N {
+		u.Get(i % 1000)
+	}
+}
+
+func BenchmarkOrderedDictSetSingle(b *testing.B) {
+	od := New[int, int]()
+	for i := range 1000 {
+		od.Set(i, i)
+	}
+
+	b.ResetTimer()
+	for i := range b.N {
+		od.Set(i%1000, i)
+	}
+}
+
+func BenchmarkUnsyncSet(b *testing.B) {
+	u := NewUnsync[int, int]()
+	for i := range 1000 {
+		u.Set(i, i)
+	}
+
+	b.ResetTimer()
+	for i := range b.N {
+		u.Set(i%1000, i)
+	}
+}
+
+func BenchmarkOrderedDictMoveToEnd(b *testing.B) {
+	od := New[int, int]()
+	for i := range 1000 {
+		od.Set(i, i)
+	}
+
+	b.ResetTimer()
+	for i := range b.N {
+		od.MoveToEnd(i % 1000)
+	}
+}
+
+func BenchmarkUnsyncMoveToEnd(b *testing.B) {
+	u := NewUnsync[int, int]()
+	for i := range 1000 {
+		u.Set(i, i)
+	}
+
+	b.ResetTimer()
+	for i := range b.N {
+		u.MoveToEnd(i % 1000)
+	}
+}
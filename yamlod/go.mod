@@ -0,0 +1,8 @@
+module github.com/amoolaa/go-ordered-dict/yamlod
+
+go 1.24.5
+
+require (
+	github.com/amoolaa/go-ordered-dict v0.1.0
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -0,0 +1,288 @@
+// Package yamlod adds YAML support to ordereddict.OrderedDict.
+//
+// The root package does not depend on a YAML library, so the marshaler and
+// unmarshaler methods live on the Dict wrapper defined here. Dict embeds the
+// wrapped *ordereddict.OrderedDict, so all of its methods remain available.
+package yamlod
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	ordereddict "github.com/amoolaa/go-ordered-dict"
+	"gopkg.in/yaml.v3"
+)
+
+// Dict wraps an OrderedDict so that it implements yaml.Marshaler and
+// yaml.Unmarshaler. A zero Dict decodes into a newly allocated OrderedDict.
+type Dict[K comparable, V any] struct {
+	*ordereddict.OrderedDict[K, V]
+}
+
+// New creates a Dict wrapping a new, empty OrderedDict.
+func New[K comparable, V any]() *Dict[K, V] {
+	return &Dict[K, V]{ordereddict.New[K, V]()}
+}
+
+// Wrap returns a Dict backed by o. Changes made through either are visible
+// in both.
+func Wrap[K comparable, V any](o *ordereddict.OrderedDict[K, V]) *Dict[K, V] {
+	return &Dict[K, V]{o}
+}
+
+// Marshal encodes o as a YAML mapping with keys in insertion order.
+func Marshal[K comparable, V any](o *ordereddict.OrderedDict[K, V]) ([]byte, error) {
+	return yaml.Marshal(Dict[K, V]{o})
+}
+
+// Unmarshal decodes a YAML mapping into o, replacing its contents.
+func Unmarshal[K comparable, V any](data []byte, o *ordereddict.OrderedDict[K, V]) error {
+	return yaml.Unmarshal(data, Wrap(o))
+}
+
+// MarshalYAML returns a mapping node whose keys appear in the same order as
+// Keys returns them. Nested OrderedDict values, including those inside
+// slices, are encoded as ordered mappings as well.
+func (d Dict[K, V]) MarshalYAML() (any, error) {
+	if d.OrderedDict == nil {
+		return nil, nil
+	}
+	return encodeNode(reflect.ValueOf(d.OrderedDict))
+}
+
+// UnmarshalYAML decodes a mapping node into the dictionary, replacing its
+// contents. Keys are inserted in document order and merge keys ("<<") are
+// honoured. When V is any, nested mappings decode into
+// *ordereddict.OrderedDict[K, any] rather than Go maps, so their order is
+// preserved too. A null node leaves the dictionary unchanged.
+func (d *Dict[K, V]) UnmarshalYAML(node *yaml.Node) error {
+	node = resolve(node)
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return nil
+	}
+
+	tmp := ordereddict.New[K, V]()
+	if err := decodeMapping(node, tmp); err != nil {
+		return err
+	}
+
+	if d.OrderedDict == nil {
+		d.OrderedDict = tmp
+		return nil
+	}
+	d.OrderedDict.Replace(tmp)
+	return nil
+}
+
+// decodeMapping inserts the entries of a mapping node into o.
+func decodeMapping[K comparable, V any](node *yaml.Node, o *ordereddict.OrderedDict[K, V]) error {
+	node = resolve(node)
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("yamlod: line %d: cannot decode %s into %T", node.Line, kindName(node), o)
+	}
+
+	// Merged entries are added first so that explicit keys override them
+	// while the merged keys keep their position, matching the common
+	// "defaults first" reading of a merge.
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if keyNode := node.Content[i]; keyNode.Tag == "!!merge" {
+			if err := decodeMerge(node.Content[i+1], o); err != nil {
+				return err
+			}
+		}
+	}
+
+	seen := make(map[K]bool, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valNode := node.Content[i], node.Content[i+1]
+		if keyNode.Tag == "!!merge" {
+			continue
+		}
+
+		var key K
+		if err := keyNode.Decode(&key); err != nil {
+			return err
+		}
+		if seen[key] {
+			return fmt.Errorf("yamlod: line %d: mapping key %q already defined", keyNode.Line, keyNode.Value)
+		}
+		seen[key] = true
+
+		val, err := decodeValue[K, V](valNode)
+		if err != nil {
+			return err
+		}
+		o.Set(key, val)
+	}
+	return nil
+}
+
+// decodeMerge inserts the entries of a merge value, which is either a single
+// mapping or a sequence of mappings, without overriding existing keys.
+func decodeMerge[K comparable, V any](node *yaml.Node, o *ordereddict.OrderedDict[K, V]) error {
+	node = resolve(node)
+	sources := []*yaml.Node{node}
+	if node.Kind == yaml.SequenceNode {
+		sources = node.Content
+	}
+	for _, src := range sources {
+		merged := ordereddict.New[K, V]()
+		if err := decodeMapping(src, merged); err != nil {
+			return err
+		}
+		for key, val := range merged.All() {
+			if !o.Has(key) {
+				o.Set(key, val)
+			}
+		}
+	}
+	return nil
+}
+
+// decodeValue decodes a value node into V. When V is any, mappings become
+// ordered dictionaries instead of Go maps.
+func decodeValue[K comparable, V any](node *yaml.Node) (V, error) {
+	var val V
+	if reflect.TypeFor[V]() != reflect.TypeFor[any]() {
+		err := node.Decode(&val)
+		return val, err
+	}
+	v, err := decodeAny[K](node)
+	if err != nil {
+		return val, err
+	}
+	if v != nil {
+		val = v.(V)
+	}
+	return val, nil
+}
+
+// decodeAny decodes a node of unknown shape, turning mappings into
+// *ordereddict.OrderedDict[K, any] and sequences into []any.
+func decodeAny[K comparable](node *yaml.Node) (any, error) {
+	node = resolve(node)
+	switch node.Kind {
+	case yaml.MappingNode:
+		nested := ordereddict.New[K, any]()
+		if err := decodeMapping(node, nested); err != nil {
+			return nil, err
+		}
+		return nested, nil
+	case yaml.SequenceNode:
+		items := make([]any, 0, len(node.Content))
+		for _, item := range node.Content {
+			v, err := decodeAny[K](item)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		}
+		return items, nil
+	}
+	var v any
+	err := node.Decode(&v)
+	return v, err
+}
+
+// encodeNode builds a YAML node for v. OrderedDicts of any instantiation
+// become ordered mapping nodes and slices are walked so that dictionaries
+// nested inside them keep their order; everything else is left to the yaml
+// package.
+func encodeNode(v reflect.Value) (*yaml.Node, error) {
+	for v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	if isOrderedDict(v.Type()) {
+		if v.IsNil() {
+			return encodeValue(nil)
+		}
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		var err error
+		yield := reflect.MakeFunc(yieldType(v), func(args []reflect.Value) []reflect.Value {
+			var keyNode, valNode *yaml.Node
+			if keyNode, err = encodeValue(args[0].Interface()); err != nil {
+				return []reflect.Value{reflect.ValueOf(false)}
+			}
+			if valNode, err = encodeNode(args[1]); err != nil {
+				return []reflect.Value{reflect.ValueOf(false)}
+			}
+			node.Content = append(node.Content, keyNode, valNode)
+			return []reflect.Value{reflect.ValueOf(true)}
+		})
+		v.MethodByName("All").Call(nil)[0].Call([]reflect.Value{yield})
+		return node, err
+	}
+
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 && !v.IsNil() {
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for i := range v.Len() {
+			item, err := encodeNode(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, item)
+		}
+		return node, nil
+	}
+
+	if !v.IsValid() {
+		return encodeValue(nil)
+	}
+	return encodeValue(v.Interface())
+}
+
+// encodeValue encodes a single value with the yaml package.
+func encodeValue(v any) (*yaml.Node, error) {
+	node := &yaml.Node{}
+	if err := node.Encode(v); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// isOrderedDict reports whether t is a pointer to an instantiation of
+// ordereddict.OrderedDict.
+func isOrderedDict(t reflect.Type) bool {
+	if t.Kind() != reflect.Pointer {
+		return false
+	}
+	e := t.Elem()
+	return e.PkgPath() == dictType.PkgPath() && strings.HasPrefix(e.Name(), "OrderedDict[")
+}
+
+var dictType = reflect.TypeFor[ordereddict.OrderedDict[int, int]]()
+
+// yieldType returns the type of the yield function accepted by the
+// iterator that v.All returns.
+func yieldType(v reflect.Value) reflect.Type {
+	return v.MethodByName("All").Type().Out(0).In(0)
+}
+
+// resolve follows document and alias nodes to the node holding the value.
+func resolve(node *yaml.Node) *yaml.Node {
+	for {
+		switch {
+		case node.Kind == yaml.DocumentNode && len(node.Content) == 1:
+			node = node.Content[0]
+		case node.Kind == yaml.AliasNode && node.Alias != nil:
+			node = node.Alias
+		default:
+			return node
+		}
+	}
+}
+
+// kindName describes a node for error messages.
+func kindName(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		return "sequence"
+	case yaml.MappingNode:
+		return "mapping"
+	case yaml.ScalarNode:
+		return "scalar " + node.Tag
+	}
+	return "node"
+}
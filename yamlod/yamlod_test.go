@@ -0,0 +1,300 @@
+package yamlod
+
+import (
+	"testing"
+
+	ordereddict "github.com/amoolaa/go-ordered-dict"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMarshal(t *testing.T) {
+	od := ordereddict.New[string, int]()
+	od.Set("z", 1)
+	od.Set("a", 2)
+	od.Set("m", 3)
+
+	b, err := Marshal(od)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "z: 1\na: 2\nm: 3\n"
+	if string(b) != expected {
+		t.Errorf("expected %q, got %q", expected, b)
+	}
+}
+
+func TestMarshalEmpty(t *testing.T) {
+	b, err := Marshal(ordereddict.New[string, int]())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(b) != "{}\n" {
+		t.Errorf("expected %q, got %q", "{}\n", b)
+	}
+}
+
+func TestMarshalNested(t *testing.T) {
+	inner := ordereddict.New[string, any]()
+	inner.Set("port", 8080)
+	inner.Set("host", "localhost")
+
+	item := ordereddict.New[string, string]()
+	item.Set("name", "b")
+	item.Set("id", "1")
+
+	od := ordereddict.New[string, any]()
+	od.Set("server", inner)
+	od.Set("items", []any{item, "plain"})
+
+	b, err := Marshal(od)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "server:\n    port: 8080\n    host: localhost\nitems:\n    - name: b\n      id: \"1\"\n    - plain\n"
+	if string(b) != expected {
+		t.Errorf("expected %q, got %q", expected, b)
+	}
+}
+
+func TestMarshalStructField(t *testing.T) {
+	type config struct {
+		Name string               `yaml:"name"`
+		Env  Dict[string, string] `yaml:"env"`
+	}
+
+	env := ordereddict.New[string, string]()
+	env.Set("PATH", "/bin")
+	env.Set("HOME", "/root")
+
+	b, err := yaml.Marshal(config{Name: "svc", Env: Dict[string, string]{env}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "name: svc\nenv:\n    PATH: /bin\n    HOME: /root\n"
+	if string(b) != expected {
+		t.Errorf("expected %q, got %q", expected, b)
+	}
+}
+
+// A dictionary that is not wrapped in a Dict has no exported fields, so
+// yaml.v3 encodes it as an empty mapping rather than failing.
+func TestMarshalUnwrappedField(t *testing.T) {
+	type config struct {
+		Cfg *ordereddict.OrderedDict[string, int] `yaml:"cfg"`
+	}
+
+	od := ordereddict.New[string, int]()
+	od.Set("a", 1)
+
+	b, err := yaml.Marshal(config{Cfg: od})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(b) != "cfg: {}\n" {
+		t.Errorf("expected %q, got %q", "cfg: {}\n", b)
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	od := ordereddict.New[string, int]()
+	od.Set("old", 1)
+
+	if err := Unmarshal([]byte("z: 1\na: 2\nm: 3\n"), od); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	keys := od.Keys()
+	expected := []string{"z", "a", "m"}
+	if len(keys) != len(expected) {
+		t.Fatalf("expected %d keys, got %d", len(expected), len(keys))
+	}
+	for i, key := range keys {
+		if key != expected[i] {
+			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
+		}
+	}
+}
+
+func TestUnmarshalAtomic(t *testing.T) {
+	od := ordereddict.New[string, int]()
+	od.Set("a", 1)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 200 {
+			if err := Unmarshal([]byte("a: 1\nb: 2\n"), od); err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			return
+		default:
+			if od.Len() == 0 {
+				t.Fatal("reader saw an empty dict during Unmarshal")
+			}
+		}
+	}
+}
+
+func TestUnmarshalLRU(t *testing.T) {
+	od := ordereddict.NewLRU[string, int](2, nil)
+	if err := Unmarshal([]byte("a: 1\nb: 2\nc: 3\n"), od); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Matches json.Unmarshal: the oldest entries are evicted.
+	keys := od.Keys()
+	if len(keys) != 2 || keys[0] != "b" || keys[1] != "c" {
+		t.Errorf("expected [b c], got %v", keys)
+	}
+}
+
+func TestUnmarshalNested(t *testing.T) {
+	input := `
+server:
+  port: 8080
+  host: localhost
+items:
+  - name: b
+    id: 1
+  - plain
+`
+	od := ordereddict.New[string, any]()
+	if err := Unmarshal([]byte(input), od); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	v, _ := od.Get("server")
+	server, ok := v.(*ordereddict.OrderedDict[string, any])
+	if !ok {
+		t.Fatalf("expected nested OrderedDict, got %T", v)
+	}
+	if keys := server.Keys(); len(keys) != 2 || keys[0] != "port" || keys[1] != "host" {
+		t.Errorf("expected [port host], got %v", keys)
+	}
+	if port, _ := server.Get("port"); port != 8080 {
+		t.Errorf("expected port=8080, got %v", port)
+	}
+
+	v, _ = od.Get("items")
+	items, ok := v.([]any)
+	if !ok || len(items) != 2 {
+		t.Fatalf("expected two items, got %#v", v)
+	}
+	item, ok := items[0].(*ordereddict.OrderedDict[string, any])
+	if !ok {
+		t.Fatalf("expected nested OrderedDict in sequence, got %T", items[0])
+	}
+	if keys := item.Keys(); len(keys) != 2 || keys[0] != "name" || keys[1] != "id" {
+		t.Errorf("expected [name id], got %v", keys)
+	}
+	if items[1] != "plain" {
+		t.Errorf("expected plain, got %v", items[1])
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	input := "zeta:\n    b: 1\n    a: [x, y]\nalpha: true\nmid: null\n"
+
+	d := New[string, any]()
+	if err := yaml.Unmarshal([]byte(input), d); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := yaml.Marshal(d)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "zeta:\n    b: 1\n    a:\n        - x\n        - \"y\"\nalpha: true\nmid: null\n"
+	if string(b) != expected {
+		t.Errorf("expected %q, got %q", expected, b)
+	}
+}
+
+func TestUnmarshalZeroDict(t *testing.T) {
+	type config struct {
+		Env Dict[string, string] `yaml:"env"`
+	}
+
+	var cfg config
+	if err := yaml.Unmarshal([]byte("env:\n  B: 2\n  A: 1\n"), &cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Env.OrderedDict == nil {
+		t.Fatal("expected dict to be allocated")
+	}
+	if keys := cfg.Env.Keys(); len(keys) != 2 || keys[0] != "B" || keys[1] != "A" {
+		t.Errorf("expected [B A], got %v", keys)
+	}
+}
+
+func TestUnmarshalMergeKeys(t *testing.T) {
+	input := `
+base: &base
+  timeout: 5
+  retries: 3
+svc:
+  <<: *base
+  name: api
+  retries: 10
+`
+	od := ordereddict.New[string, any]()
+	if err := Unmarshal([]byte(input), od); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	v, _ := od.Get("svc")
+	svc := v.(*ordereddict.OrderedDict[string, any])
+	keys := svc.Keys()
+	expected := []string{"timeout", "retries", "name"}
+	if len(keys) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, keys)
+	}
+	for i, key := range keys {
+		if key != expected[i] {
+			t.Errorf("position %d: expected %s, got %s", i, expected[i], key)
+		}
+	}
+	if retries, _ := svc.Get("retries"); retries != 10 {
+		t.Errorf("expected explicit key to override merge, got %v", retries)
+	}
+}
+
+func TestUnmarshalIntKeys(t *testing.T) {
+	od := ordereddict.New[int, string]()
+	if err := Unmarshal([]byte("3: c\n1: a\n"), od); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if keys := od.Keys(); len(keys) != 2 || keys[0] != 3 || keys[1] != 1 {
+		t.Errorf("expected [3 1], got %v", keys)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"sequence", "- a\n- b\n"},
+		{"scalar", "hello\n"},
+		{"bad value", "a: not a number\n"},
+		{"duplicate key", "a: 1\na: 2\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			od := ordereddict.New[string, int]()
+			od.Set("keep", 1)
+			if err := Unmarshal([]byte(tt.input), od); err == nil {
+				t.Error("expected error")
+			}
+			if val, ok := od.Get("keep"); !ok || val != 1 {
+				t.Error("dict should be unchanged after a failed decode")
+			}
+		})
+	}
+}